@@ -1,8 +1,14 @@
 package adapter
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // Scope represents the precedence level of a prompt pack.
@@ -33,13 +39,91 @@ type PromptPack struct {
 	Path   string // Local filesystem path to the pack
 	Source string // Source name (e.g., "shopify")
 	Ref    string // Git ref (branch, tag, or commit)
+	Prefix string // File name prefix declared for the source, if any
+
+	// Prompts holds the pack's prompts keyed by path relative to the pack
+	// root, parsed once by the installer; nil when not parsed yet
+	Prompts map[string]Prompt
+
+	// Vars are the template variables of the Promptsfile and
+	// Promptsfile.local, overriding the pack's defaults
+	Vars map[string]interface{}
+
+	// StrictVars fails rendering when a prompt uses an undefined variable
+	StrictVars bool
+
+	// Warnings collects the warnings of rendering the pack; they are
+	// printed right away when nil
+	Warnings *Warnings
+}
+
+// Warnings collects warnings raised while rendering so that the installer
+// can print them in a fixed order once concurrent rendering is done.
+type Warnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// Add records a warning, or prints it when w is nil
+func (w *Warnings) Add(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if w == nil {
+		fmt.Printf("Warning: %s\n", message)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, message)
+}
+
+// Messages returns the recorded warnings in the order they were added
+func (w *Warnings) Messages() []string {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.messages...)
 }
 
 // RenderedFile represents a file generated by an adapter.
 type RenderedFile struct {
-	Path    string // Relative path within target directory
-	Content []byte // File content
-	Hash    string // SHA256 hash of content
+	Path       string // Relative path within target directory
+	SourcePath string // Path of the prompt file relative to the pack root
+	Content    []byte // File content
+	Hash       string // SHA256 hash of content
+
+	// Fragment marks content that is combined with the fragments other packs
+	// render at the same path, see Composer
+	Fragment bool
+
+	// Section marks fragments composed into the managed section of a file
+	// that may also hold hand-written content
+	Section bool
+}
+
+// Fragment is the part of a composed file rendered from one prompt file.
+type Fragment struct {
+	Pack       string // Pack the fragment was rendered from
+	Scope      Scope
+	SourcePath string
+	Content    []byte
+}
+
+// Scanner is implemented by adapters that do not render into a single flat
+// directory. Only the returned directories are checked for duplicate
+// basenames, instead of TargetDir.
+type Scanner interface {
+	ScanDirs() []string
+}
+
+// Composer is implemented by adapters that render fragments. Fragments that
+// share a path are not shadowed by higher scopes; the adapter combines them
+// into a single file instead.
+type Composer interface {
+	// Compose builds the file at path from fragments ordered from the lowest
+	// to the highest scope
+	Compose(path string, fragments []Fragment) ([]byte, error)
 }
 
 // AgentAdapter defines the interface for rendering prompt packs
@@ -59,6 +143,10 @@ type AgentAdapter interface {
 
 	// Verify checks that rendered files match expected hashes
 	Verify(files []RenderedFile, mode Strictness) error
+
+	// GitignorePatterns returns the .gitignore patterns covering the files
+	// rendered for the given packs
+	GitignorePatterns(packs []PromptPack) []string
 }
 
 // HashContent computes the SHA256 hash of content.
@@ -67,61 +155,100 @@ func HashContent(content []byte) string {
 	return hex.EncodeToString(h[:])
 }
 
-// Stub implementations moved to their own packages
-// Use cursor.NewAdapter() and claude.NewAdapter() instead
-
-// Temporary stubs for backward compatibility
-func NewCursorAdapter() AgentAdapter {
-	// This will be replaced with cursor.NewAdapter()
-	return &cursorAdapter{}
-}
-
-type cursorAdapter struct{}
-
-func (a *cursorAdapter) Name() string {
-	return "cursor"
-}
-
-func (a *cursorAdapter) Detect() bool {
-	return false
-}
-
-func (a *cursorAdapter) TargetDir(scope Scope) string {
-	return ".cursor/rules/_active"
-}
-
-func (a *cursorAdapter) Render(pack PromptPack, scope Scope) ([]RenderedFile, error) {
-	return nil, nil
-}
-
-func (a *cursorAdapter) Verify(files []RenderedFile, mode Strictness) error {
+// JoinFragments concatenates fragments, marking where each one came from
+func JoinFragments(fragments []Fragment) []byte {
+	var buf bytes.Buffer
+	for idx, fragment := range fragments {
+		if idx > 0 {
+			buf.WriteString("\n")
+		}
+		origin := fmt.Sprintf("%s %s", fragment.Pack, fragment.SourcePath)
+		fmt.Fprintf(&buf, "<!-- prompt-sync: begin %s -->\n", origin)
+		buf.Write(bytes.TrimRight(fragment.Content, "\r\n"))
+		fmt.Fprintf(&buf, "\n<!-- prompt-sync: end %s -->\n", origin)
+	}
+	return buf.Bytes()
+}
+
+// VerifyHashes checks that rendered files match their hashes. In normal mode
+// a partial mismatch is tolerated; strict mode fails on any mismatch.
+func VerifyHashes(files []RenderedFile, mode Strictness) error {
+	var errors []string
+	for _, file := range files {
+		actualHash := HashContent(file.Content)
+		if actualHash != file.Hash {
+			msg := fmt.Sprintf("hash mismatch for %s: expected %s, got %s", file.Path, file.Hash, actualHash)
+			errors = append(errors, msg)
+		}
+	}
+
+	if len(errors) > 0 && (mode == StrictnessStrict || len(errors) == len(files)) {
+		return fmt.Errorf("verification failed: %s", strings.Join(errors, "; "))
+	}
 	return nil
 }
 
-func NewClaudeAdapter(prefix string) AgentAdapter {
-	return &claudeAdapter{prefix: prefix}
-}
-
-type claudeAdapter struct {
-	prefix string
-}
-
-func (a *claudeAdapter) Name() string {
-	return "claude"
-}
-
-func (a *claudeAdapter) Detect() bool {
+// PromptsDir returns the directory holding a pack's prompts: prompts/, or the
+// first fallback directory that exists. It is empty when the pack has none.
+func PromptsDir(packPath string, fallbacks ...string) string {
+	for _, name := range append([]string{"prompts"}, fallbacks...) {
+		dir := filepath.Join(packPath, name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// MarkdownFiles returns the markdown files below dir in lexical order
+func MarkdownFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isMarkdownFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// isMarkdownFile checks if a file is a markdown file.
+func isMarkdownFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown" || ext == ".mdc"
+}
+
+// StringList reads a front-matter field given as a list or as a comma
+// separated string, such as globs
+func StringList(value interface{}) []string {
+	var result []string
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+// IsTrue reads a boolean front-matter field that may also be written as a string
+func IsTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
 	return false
 }
-
-func (a *claudeAdapter) TargetDir(scope Scope) string {
-	return ".claude/commands"
-}
-
-func (a *claudeAdapter) Render(pack PromptPack, scope Scope) ([]RenderedFile, error) {
-	return nil, nil
-}
-
-func (a *claudeAdapter) Verify(files []RenderedFile, mode Strictness) error {
-	return nil
-}
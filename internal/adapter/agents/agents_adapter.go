@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+)
+
+const fileName = "AGENTS.md"
+
+// Adapter implements the AgentAdapter interface for agents that read a single
+// AGENTS.md instructions file, such as Codex.
+//
+// Rules from every pack are composed into the managed section of AGENTS.md at
+// the repository root, or of <dir>/AGENTS.md for each directory listed in a
+// rule's paths: front-matter. Content outside the managed section is kept.
+type Adapter struct{}
+
+// NewAdapter creates a new AGENTS.md adapter.
+func NewAdapter() adapter.AgentAdapter {
+	return &Adapter{}
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return "agents"
+}
+
+// Detect checks if the repository has an AGENTS.md file.
+func (a *Adapter) Detect() bool {
+	_, err := os.Stat(fileName)
+	return err == nil
+}
+
+// TargetDir returns the repository root, AGENTS.md files live next to the
+// code they describe.
+func (a *Adapter) TargetDir(scope adapter.Scope) string {
+	return "."
+}
+
+// Render converts the rules of a prompt pack into AGENTS.md section fragments.
+// Prompts with a kind other than rule are left to other adapters.
+func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
+	dir := adapter.PromptsDir(pack.Path, "rules")
+	if dir == "" {
+		return nil, nil
+	}
+
+	prompts, err := adapter.Prompts(pack, dir, a.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var files []adapter.RenderedFile
+	for _, prompt := range prompts {
+		if prompt.Kind != "" && prompt.Kind != "rule" {
+			continue
+		}
+
+		dirs := adapter.StringList(prompt.Meta["paths"])
+		if len(dirs) == 0 {
+			dirs = []string{"."}
+		}
+		for _, target := range dirs {
+			if !filepath.IsLocal(target) {
+				return nil, fmt.Errorf("invalid paths entry %q in %s: must be a directory inside the repository", target, prompt.SourcePath)
+			}
+			files = append(files, adapter.RenderedFile{
+				Path:       filepath.Join(target, fileName),
+				SourcePath: prompt.SourcePath,
+				Content:    prompt.Body,
+				Hash:       adapter.HashContent(prompt.Body),
+				Fragment:   true,
+				Section:    true,
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// Compose builds the managed section of an AGENTS.md file from its rules.
+func (a *Adapter) Compose(path string, fragments []adapter.Fragment) ([]byte, error) {
+	return adapter.JoinFragments(fragments), nil
+}
+
+// Verify checks that rendered files match expected hashes.
+func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
+	return adapter.VerifyHashes(files, mode)
+}
+
+// GitignorePatterns returns no patterns: AGENTS.md files hold hand-written
+// content and stay under version control.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	return nil
+}
@@ -5,19 +5,35 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/kovyrin/prompt-sync/internal/adapter"
 )
 
+const (
+	commandsDir = ".claude/commands"
+	agentsDir   = ".claude/agents"
+	skillsDir   = ".claude/skills"
+	memoryFile  = "CLAUDE.md"
+)
+
 // Adapter implements the AgentAdapter interface for Claude.
+//
+// Prompts are routed by their kind: front-matter. Commands (the default)
+// become .claude/commands/<prefix>-<name>.md, agents
+// .claude/agents/<prefix>-<name>.md and skills
+// .claude/skills/<prefix>-<name>/SKILL.md with the rest of their directory.
+// Rules from every pack are composed into the managed section of CLAUDE.md.
 type Adapter struct {
-	prefix string
+	prefix string // Prefix configured in the Promptsfile
+	naming string // Naming strategy of commands and agents
 }
 
-// NewAdapter creates a new Claude adapter with the given prefix.
-func NewAdapter(prefix string) adapter.AgentAdapter {
-	return &Adapter{prefix: prefix}
+// NewAdapter creates a new Claude adapter with the given prefix and naming
+// strategy. Packs that declare their own prefix override it.
+func NewAdapter(prefix, naming string) adapter.AgentAdapter {
+	return &Adapter{prefix: prefix, naming: naming}
 }
 
 // Name returns the adapter name.
@@ -35,88 +51,202 @@ func (a *Adapter) Detect() bool {
 	return false
 }
 
-// TargetDir returns the directory where Claude commands should be rendered.
+// TargetDir returns the repository root: Claude files live in .claude and
+// in CLAUDE.md next to it.
 func (a *Adapter) TargetDir(scope adapter.Scope) string {
-	// Claude uses a single directory for all commands
-	return ".claude/commands"
+	return "."
 }
 
-// Render converts a prompt pack into Claude command files.
+// ScanDirs returns the flat directories checked for duplicate basenames.
+// Skills are not scanned, each of them has a SKILL.md, and neither are nested
+// layouts.
+func (a *Adapter) ScanDirs() []string {
+	if a.naming == adapter.NamingNested {
+		return nil
+	}
+	return []string{commandsDir, agentsDir}
+}
+
+// Render converts a prompt pack into Claude files. Prompts are read from
+// prompts/, or commands/ when the pack has no prompts directory. Prompts with
+// other kinds are left to other adapters.
 func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
-	promptsDir := filepath.Join(pack.Path, "prompts")
-	if _, err := os.Stat(promptsDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("prompts directory not found: %s", promptsDir)
+	promptsDir := adapter.PromptsDir(pack.Path, "commands")
+	if promptsDir == "" {
+		return nil, nil
+	}
+
+	paths, err := adapter.MarkdownFiles(promptsDir)
+	if err != nil {
+		return nil, err
+	}
+	skillDirs := adapter.SkillDirs(promptsDir, paths)
+
+	prompts, err := adapter.Prompts(pack, promptsDir, a.Name())
+	if err != nil {
+		return nil, err
 	}
 
+	prefix := a.packPrefix(pack)
+
 	var files []adapter.RenderedFile
+	for _, p := range prompts {
+		dir := filepath.Dir(p.Path)
+		withDir := skillDirs[dir] && adapter.IsSkillFile(p.Path)
+		if adapter.InSkillDir(skillDirs, dir) && !withDir {
+			continue // Rendered with its skill
+		}
 
-	// Walk through all markdown files in the prompts directory
-	err := filepath.Walk(promptsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+		kind := p.Kind
+		if kind == "" {
+			kind = "command"
+			if withDir {
+				kind = "skill"
+			}
 		}
 
-		// Skip directories and non-markdown files
-		if info.IsDir() || !isMarkdownFile(path) {
-			return nil
+		switch kind {
+		case "command":
+			files = append(files, rawFile(filepath.Join(commandsDir, a.fileName(pack, prefix, promptsDir, p.Path)), p.SourcePath, p.Content))
+		case "agent":
+			files = append(files, rawFile(filepath.Join(agentsDir, a.fileName(pack, prefix, promptsDir, p.Path)), p.SourcePath, p.Content))
+		case "skill":
+			skill, err := renderSkill(pack.Path, prefix, p, withDir)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, skill...)
+		case "rule":
+			files = append(files, adapter.RenderedFile{
+				Path:       memoryFile,
+				SourcePath: p.SourcePath,
+				Content:    p.Body,
+				Hash:       adapter.HashContent(p.Body),
+				Fragment:   true,
+				Section:    true,
+			})
 		}
+	}
 
-		// Skip metadata.yaml
-		if filepath.Base(path) == "metadata.yaml" {
-			return nil
+	return files, nil
+}
+
+// Compose builds the managed section of CLAUDE.md from its rules.
+func (a *Adapter) Compose(path string, fragments []adapter.Fragment) ([]byte, error) {
+	return adapter.JoinFragments(fragments), nil
+}
+
+// GitignorePatterns returns patterns for every location per command prefix
+// used by the packs, or whole directories when a pack renders unprefixed
+// files. CLAUDE.md holds hand-written content and stays under version control.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, pack := range packs {
+		prefix := a.packPrefix(pack)
+		if prefix == "" {
+			return []string{commandsDir + "/*", agentsDir + "/*", skillsDir + "/*"}
+		}
+		if !seen[prefix] {
+			seen[prefix] = true
+			files := prefix + "-*"
+			if a.naming == adapter.NamingNested {
+				files = prefix + "/"
+			}
+			patterns = append(patterns,
+				fmt.Sprintf("%s/%s", commandsDir, files),
+				fmt.Sprintf("%s/%s", agentsDir, files),
+				fmt.Sprintf("%s/%s-*/", skillsDir, prefix))
 		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
 
-		// Read the file
-		content, err := os.ReadFile(path)
+// fileName returns the name of a command or agent below its directory. Nested
+// layouts use the prefix as a namespace directory.
+func (a *Adapter) fileName(pack adapter.PromptPack, prefix, promptsDir, path string) string {
+	switch a.naming {
+	case adapter.NamingNested:
+		rel, err := filepath.Rel(promptsDir, path)
 		if err != nil {
-			return fmt.Errorf("read file %s: %w", path, err)
+			rel = filepath.Base(path)
 		}
+		return filepath.Join(prefix, rel)
+	case adapter.NamingPath:
+		return GenerateFileName(prefix, adapter.OutputName(adapter.NamingPath, pack, promptsDir, path))
+	default:
+		return GenerateFileName(prefix, path)
+	}
+}
+
+// packPrefix resolves the command prefix used for a pack
+func (a *Adapter) packPrefix(pack adapter.PromptPack) string {
+	return ResolvePrefix(pack.Prefix, a.prefix, pack.Source)
+}
+
+// rawFile renders content unchanged, Claude reads plain markdown files
+func rawFile(path, sourcePath string, content []byte) adapter.RenderedFile {
+	return adapter.RenderedFile{
+		Path:       path,
+		SourcePath: sourcePath,
+		Content:    content,
+		Hash:       adapter.HashContent(content),
+	}
+}
+
+// renderSkill renders a skill into its own directory. A SKILL.md brings the
+// other files of its directory along, copied as they are; any other file is a
+// single-file skill named after the file.
+func renderSkill(packPath, prefix string, p adapter.Prompt, withDir bool) ([]adapter.RenderedFile, error) {
+	if !withDir {
+		name := strings.TrimSuffix(GenerateFileName(prefix, p.Path), filepath.Ext(p.Path))
+		return []adapter.RenderedFile{rawFile(filepath.Join(skillsDir, name, adapter.SkillFile), p.SourcePath, p.Content)}, nil
+	}
+
+	dir := filepath.Dir(p.Path)
+	skillDir := filepath.Join(skillsDir, GenerateFileName(prefix, dir))
 
-		// Get relative path for the file
-		relPath, err := filepath.Rel(promptsDir, path)
+	var files []adapter.RenderedFile
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if info.IsDir() {
+			return nil
+		}
 
-		// Generate the prefixed filename
-		fileName := GenerateFileName(a.prefix, relPath)
+		if path == p.Path {
+			// SKILL.md itself was loaded with templating and includes applied
+			files = append(files, rawFile(filepath.Join(skillDir, adapter.SkillFile), p.SourcePath, p.Content))
+			return nil
+		}
 
-		// Create rendered file
-		renderedFile := adapter.RenderedFile{
-			Path:    fileName,
-			Content: content, // Claude uses raw markdown files
-			Hash:    adapter.HashContent(content),
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sourcePath, err := filepath.Rel(packPath, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read file %s: %w", path, err)
 		}
 
-		files = append(files, renderedFile)
+		files = append(files, rawFile(filepath.Join(skillDir, relPath), sourcePath, content))
 		return nil
 	})
-
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("render skill %s: %w", dir, err)
 	}
-
 	return files, nil
 }
 
 // Verify checks that rendered files match expected hashes.
 func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
-	var errors []string
-	for _, file := range files {
-		actualHash := adapter.HashContent(file.Content)
-		if actualHash != file.Hash {
-			msg := fmt.Sprintf("hash mismatch for %s: expected %s, got %s", file.Path, file.Hash, actualHash)
-			errors = append(errors, msg)
-		}
-	}
-
-	if len(errors) > 0 {
-		if mode == adapter.StrictnessStrict || len(errors) == len(files) {
-			return fmt.Errorf("verification failed: %s", strings.Join(errors, "; "))
-		}
-		// In normal mode, we would log warnings but continue
-	}
-	return nil
+	return adapter.VerifyHashes(files, mode)
 }
 
 // ResolvePrefix determines the prefix to use based on precedence rules.
@@ -135,7 +265,8 @@ func ResolvePrefix(sourcePrefix, configPrefix, sourceName string) string {
 	return ToKebabCase(sourceName)
 }
 
-// GenerateFileName creates a prefixed filename for Claude.
+// GenerateFileName creates a prefixed filename for Claude. An empty prefix
+// leaves the filename unprefixed.
 func GenerateFileName(prefix, filePath string) string {
 	// Get just the filename
 	fileName := filepath.Base(filePath)
@@ -146,6 +277,10 @@ func GenerateFileName(prefix, filePath string) string {
 	// Replace underscores with dashes
 	fileName = strings.ReplaceAll(fileName, "_", "-")
 
+	if prefix == "" {
+		return fileName
+	}
+
 	// Prefix the filename
 	return fmt.Sprintf("%s-%s", prefix, fileName)
 }
@@ -187,12 +322,6 @@ func ToKebabCase(s string) string {
 	return result
 }
 
-// isMarkdownFile checks if a file is a markdown file.
-func isMarkdownFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".md" || ext == ".markdown" || ext == ".mdc"
-}
-
 // isUpper checks if a rune is uppercase.
 func isUpper(r rune) bool {
 	return r >= 'A' && r <= 'Z'
@@ -0,0 +1,164 @@
+package copilot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+)
+
+const (
+	instructionsFile = "copilot-instructions.md"
+	instructionsDir  = "instructions"
+	promptsDir       = "prompts"
+)
+
+// Adapter implements the AgentAdapter interface for GitHub Copilot.
+//
+// Always-on rules (alwaysApply: true) from every pack are composed into the
+// managed section of .github/copilot-instructions.md, other rules become
+// .github/instructions/<name>.instructions.md with an applyTo glob taken from
+// their globs, and command-style prompts (kind: command) become
+// .github/prompts/<name>.prompt.md.
+type Adapter struct {
+	naming string // Naming strategy of instruction and prompt files
+}
+
+// NewAdapter creates a new Copilot adapter using the given naming strategy.
+func NewAdapter(naming string) adapter.AgentAdapter {
+	return &Adapter{naming: naming}
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return "copilot"
+}
+
+// Detect checks if Copilot instructions are present.
+func (a *Adapter) Detect() bool {
+	for _, path := range []string{".github/" + instructionsFile, ".github/" + instructionsDir} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetDir returns the directory where Copilot files should be rendered.
+func (a *Adapter) TargetDir(scope adapter.Scope) string {
+	return ".github"
+}
+
+// Render converts a prompt pack into Copilot instruction and prompt files.
+// Always-on rules are rendered as fragments of the managed section of the
+// shared instructions file. Agents and skills are left to other adapters.
+func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
+	dir := adapter.PromptsDir(pack.Path, "rules")
+	if dir == "" {
+		return nil, nil
+	}
+
+	prompts, err := adapter.Prompts(pack, dir, a.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var files []adapter.RenderedFile
+	for _, prompt := range prompts {
+		if prompt.Kind != "" && prompt.Kind != "rule" && prompt.Kind != "command" {
+			continue
+		}
+		meta, body := prompt.Meta, prompt.Body
+		file := adapter.RenderedFile{SourcePath: prompt.SourcePath}
+		name := strings.TrimSuffix(adapter.OutputName(a.naming, pack, dir, prompt.Path), filepath.Ext(prompt.Path))
+		description, _ := meta["description"].(string)
+
+		switch {
+		case prompt.Kind == "command":
+			file.Path = filepath.Join(promptsDir, name+".prompt.md")
+			file.Content = withFrontMatter(promptFrontMatter{Description: description}, body)
+		case adapter.IsTrue(meta["alwaysApply"]):
+			file.Path = instructionsFile
+			file.Content = body
+			file.Fragment = true
+			file.Section = true
+		default:
+			file.Path = filepath.Join(instructionsDir, name+".instructions.md")
+			file.Content = withFrontMatter(instructionsFrontMatter{
+				Description: description,
+				ApplyTo:     strings.Join(adapter.StringList(meta["globs"]), ","),
+			}, body)
+		}
+		file.Hash = adapter.HashContent(file.Content)
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Compose builds the managed section of copilot-instructions.md from the
+// always-on rules.
+func (a *Adapter) Compose(path string, fragments []adapter.Fragment) ([]byte, error) {
+	return adapter.JoinFragments(fragments), nil
+}
+
+// Verify checks that rendered files match expected hashes.
+func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
+	return adapter.VerifyHashes(files, mode)
+}
+
+// ScanDirs returns the directories checked for duplicate basenames. Nested
+// layouts keep files with the same name apart, so they are not checked.
+func (a *Adapter) ScanDirs() []string {
+	if a.naming == adapter.NamingNested {
+		return nil
+	}
+	dir := a.TargetDir(adapter.ScopeProject)
+	return []string{filepath.Join(dir, instructionsDir), filepath.Join(dir, promptsDir)}
+}
+
+// GitignorePatterns returns the patterns covering rendered Copilot files.
+// copilot-instructions.md holds hand-written content and stays under version
+// control.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	dir := a.TargetDir(adapter.ScopeProject)
+	files := "/"
+	if a.naming == adapter.NamingNested {
+		files = "/**/"
+	}
+	return []string{
+		dir + "/" + instructionsDir + files + "*.instructions.md",
+		dir + "/" + promptsDir + files + "*.prompt.md",
+	}
+}
+
+// instructionsFrontMatter is the front-matter of a .instructions.md file
+type instructionsFrontMatter struct {
+	Description string `yaml:"description,omitempty"`
+	ApplyTo     string `yaml:"applyTo,omitempty"`
+}
+
+// promptFrontMatter is the front-matter of a .prompt.md file
+type promptFrontMatter struct {
+	Description string `yaml:"description,omitempty"`
+}
+
+// withFrontMatter renders body below the non-empty fields of frontMatter
+func withFrontMatter(frontMatter interface{}, body []byte) []byte {
+	yamlBytes, _ := yaml.Marshal(frontMatter)
+	if string(yamlBytes) == "{}\n" {
+		return body
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(yamlBytes)
+	buf.WriteString("---\n\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
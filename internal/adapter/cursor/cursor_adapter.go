@@ -5,18 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/kovyrin/prompt-sync/internal/adapter"
 	"gopkg.in/yaml.v3"
 )
 
+// ruleExt is the extension of Cursor rule files
+const ruleExt = ".mdc"
+
 // Adapter implements the AgentAdapter interface for Cursor.
-type Adapter struct{}
+type Adapter struct {
+	naming string // Naming strategy of rule files
+}
 
-// NewAdapter creates a new Cursor adapter.
-func NewAdapter() adapter.AgentAdapter {
-	return &Adapter{}
+// NewAdapter creates a new Cursor adapter using the given naming strategy.
+func NewAdapter(naming string) adapter.AgentAdapter {
+	return &Adapter{naming: naming}
 }
 
 // Name returns the adapter name.
@@ -40,231 +47,176 @@ func (a *Adapter) TargetDir(scope adapter.Scope) string {
 	return ".cursor/rules/_active"
 }
 
-// Render converts a prompt pack into Cursor rule files.
+// Render converts a prompt pack into Cursor .mdc rules. Prompts are read
+// from prompts/, or rules/ when the pack has no prompts directory; prompts
+// with a kind other than rule are left to other adapters. The description,
+// globs and alwaysApply fields are validated and normalized; other fields
+// are kept after them with a warning.
 func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
-	promptsDir := filepath.Join(pack.Path, "prompts")
-	if _, err := os.Stat(promptsDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("prompts directory not found: %s", promptsDir)
+	promptsDir := adapter.PromptsDir(pack.Path, "rules")
+	if promptsDir == "" {
+		return nil, nil
 	}
 
-	// Load metadata.yaml if it exists
-	metadataPath := filepath.Join(promptsDir, "metadata.yaml")
-	metadata, _ := LoadMetadataFile(metadataPath) // Ignore error if file doesn't exist
+	prompts, err := adapter.Prompts(pack, promptsDir, a.Name())
+	if err != nil {
+		return nil, err
+	}
 
 	var files []adapter.RenderedFile
-
-	// Walk through all markdown files in the prompts directory
-	err := filepath.Walk(promptsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories and non-markdown files
-		if info.IsDir() || !isMarkdownFile(path) {
-			return nil
+	for _, prompt := range prompts {
+		if prompt.Kind != "" && prompt.Kind != "rule" {
+			continue
 		}
-
-		// Skip metadata.yaml
-		if filepath.Base(path) == "metadata.yaml" {
-			return nil
-		}
-
-		// Read the file
-		content, err := os.ReadFile(path)
+		r, unknown, err := parseRule(prompt.Meta)
 		if err != nil {
-			return fmt.Errorf("read file %s: %w", path, err)
+			return nil, fmt.Errorf("%s: %w", prompt.SourcePath, err)
 		}
-
-		// Parse front-matter
-		frontMatter, body, err := ParseFrontMatter(content)
-		if err != nil {
-			return fmt.Errorf("parse front-matter in %s: %w", path, err)
+		for _, key := range unknown {
+			pack.Warnings.Add("%s: unknown Cursor rule field %q", prompt.SourcePath, key)
 		}
-
-		// Get relative path for the file
-		relPath, err := filepath.Rel(promptsDir, path)
+		_, hasDescription := prompt.Meta["description"]
+		_, hasGlobs := prompt.Meta["globs"]
+		_, hasAlwaysApply := prompt.Meta["alwaysApply"]
+		rendered, err := renderCursorRule(r, hasDescription || hasGlobs || hasAlwaysApply || len(unknown) > 0, prompt.Meta, unknown, prompt.Body)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("%s: %w", prompt.SourcePath, err)
 		}
 
-		// Merge metadata (defaults -> file overrides -> front-matter)
-		fileOverride := metadata.Files[filepath.Base(path)]
-		effectiveMeta := MergeMetadata(metadata.Defaults, fileOverride, frontMatter)
-
-		// Render the file with merged metadata
-		rendered := renderCursorRule(effectiveMeta, body)
+		// Rules are written as .mdc files whatever the source extension
+		name := adapter.OutputName(a.naming, pack, promptsDir, prompt.Path)
+		files = append(files, adapter.RenderedFile{
+			Path:       strings.TrimSuffix(name, filepath.Ext(name)) + ruleExt,
+			SourcePath: prompt.SourcePath,
+			Content:    rendered,
+			Hash:       adapter.HashContent(rendered),
+		})
+	}
 
-		// Create rendered file
-		renderedFile := adapter.RenderedFile{
-			Path:    relPath,
-			Content: rendered,
-			Hash:    adapter.HashContent(rendered),
-		}
+	return files, nil
+}
 
-		files = append(files, renderedFile)
+// ScanDirs returns the directories checked for duplicate basenames. Nested
+// layouts keep files with the same name apart, so they are not checked.
+func (a *Adapter) ScanDirs() []string {
+	if a.naming == adapter.NamingNested {
 		return nil
-	})
-
-	if err != nil {
-		return nil, err
 	}
+	return []string{a.TargetDir(adapter.ScopeProject)}
+}
 
-	return files, nil
+// GitignorePatterns returns the patterns covering rendered Cursor rules.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	return []string{a.TargetDir(adapter.ScopeProject) + "/"}
 }
 
 // Verify checks that rendered files match expected hashes.
 func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
-	var errors []string
-	for _, file := range files {
-		actualHash := adapter.HashContent(file.Content)
-		if actualHash != file.Hash {
-			msg := fmt.Sprintf("hash mismatch for %s: expected %s, got %s", file.Path, file.Hash, actualHash)
-			errors = append(errors, msg)
-		}
-	}
-
-	if len(errors) > 0 {
-		if mode == adapter.StrictnessStrict || len(errors) == len(files) {
-			return fmt.Errorf("verification failed: %s", strings.Join(errors, "; "))
-		}
-		// In normal mode, we would log warnings but continue
-	}
-	return nil
+	return adapter.VerifyHashes(files, mode)
 }
 
-// Metadata represents the structure of metadata.yaml
-type Metadata struct {
-	Defaults map[string]interface{}            `yaml:"defaults"`
-	Files    map[string]map[string]interface{} `yaml:"files"`
+// promptSyncFields are front-matter fields read by prompt-sync or other
+// adapters; they are left out of rules
+var promptSyncFields = map[string]bool{
+	"kind":     true,
+	"targets":  true,
+	"security": true,
+	"paths":    true,
+	"trigger":  true,
+	"name":     true,
+	"template": true,
+	"include":  true,
 }
 
-// LoadMetadataFile loads and parses a metadata.yaml file.
-func LoadMetadataFile(path string) (*Metadata, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Return empty metadata if file doesn't exist
-			return &Metadata{
-				Defaults: make(map[string]interface{}),
-				Files:    make(map[string]map[string]interface{}),
-			}, nil
-		}
-		return nil, err
-	}
-
-	var metadata Metadata
-	if err := yaml.Unmarshal(data, &metadata); err != nil {
-		return nil, fmt.Errorf("parse metadata.yaml: %w", err)
-	}
-
-	// Initialize maps if nil
-	if metadata.Defaults == nil {
-		metadata.Defaults = make(map[string]interface{})
-	}
-	if metadata.Files == nil {
-		metadata.Files = make(map[string]map[string]interface{})
-	}
-
-	return &metadata, nil
+// rule holds the normalized front-matter fields of a Cursor rule
+type rule struct {
+	Description string
+	Globs       []string
+	AlwaysApply bool
 }
 
-// ParseFrontMatter extracts YAML front-matter from markdown content.
-func ParseFrontMatter(content []byte) (map[string]interface{}, []byte, error) {
-	// Check if content starts with ---
-	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
-		// No front-matter
-		return make(map[string]interface{}), content, nil
-	}
-
-	// Find the closing ---
-	lines := bytes.Split(content, []byte("\n"))
-	var endIndex int
-	for i := 1; i < len(lines); i++ {
-		line := bytes.TrimSpace(lines[i])
-		if bytes.Equal(line, []byte("---")) {
-			endIndex = i
-			break
+// parseRule validates the Cursor fields of merged metadata and returns the
+// unknown fields, sorted
+func parseRule(metadata map[string]interface{}) (rule, []string, error) {
+	var r rule
+	var unknown []string
+	for key, value := range metadata {
+		switch key {
+		case "description":
+			switch v := value.(type) {
+			case string:
+				r.Description = v
+			case bool, int, float64:
+				r.Description = fmt.Sprint(v)
+			default:
+				return rule{}, nil, fmt.Errorf("invalid description: must be a string")
+			}
+		case "globs":
+			switch v := value.(type) {
+			case string:
+				r.Globs = adapter.StringList(v)
+			case []interface{}:
+				for _, item := range v {
+					glob, ok := item.(string)
+					if !ok {
+						return rule{}, nil, fmt.Errorf("invalid globs entry %v: must be a string", item)
+					}
+					r.Globs = append(r.Globs, adapter.StringList(glob)...)
+				}
+			default:
+				return rule{}, nil, fmt.Errorf("invalid globs: must be a list or a comma separated string")
+			}
+		case "alwaysApply":
+			switch v := value.(type) {
+			case bool:
+				r.AlwaysApply = v
+			case string:
+				parsed, err := strconv.ParseBool(v)
+				if err != nil {
+					return rule{}, nil, fmt.Errorf("invalid alwaysApply %q: must be true or false", v)
+				}
+				r.AlwaysApply = parsed
+			default:
+				return rule{}, nil, fmt.Errorf("invalid alwaysApply %v: must be true or false", v)
+			}
+		default:
+			if !promptSyncFields[key] {
+				unknown = append(unknown, key)
+			}
 		}
 	}
-
-	if endIndex == 0 {
-		// No closing ---, treat as no front-matter
-		return make(map[string]interface{}), content, nil
-	}
-
-	// Extract front-matter
-	frontMatterBytes := bytes.Join(lines[1:endIndex], []byte("\n"))
-
-	// Parse YAML
-	var frontMatter map[string]interface{}
-	if err := yaml.Unmarshal(frontMatterBytes, &frontMatter); err != nil {
-		return nil, nil, fmt.Errorf("parse YAML front-matter: %w", err)
-	}
-
-	if frontMatter == nil {
-		frontMatter = make(map[string]interface{})
-	}
-
-	// Extract body (everything after the closing ---)
-	bodyLines := lines[endIndex+1:]
-	body := bytes.Join(bodyLines, []byte("\n"))
-	body = bytes.TrimLeft(body, "\n\r")
-
-	return frontMatter, body, nil
+	sort.Strings(unknown)
+	return r, unknown, nil
 }
 
-// MergeMetadata merges metadata from three sources with proper precedence.
-func MergeMetadata(defaults, fileOverride, frontMatter map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	// Start with defaults
-	for k, v := range defaults {
-		result[k] = v
-	}
-
-	// Apply file overrides
-	for k, v := range fileOverride {
-		if v == nil {
-			// nil means remove the field
-			delete(result, k)
-		} else {
-			result[k] = v
-		}
+// renderCursorRule renders a Cursor rule with normalized front-matter
+// followed by the unknown fields as written, or the body alone when the
+// prompt sets no front-matter fields. Globs are written comma separated and
+// unquoted, the way Cursor writes them.
+func renderCursorRule(r rule, set bool, metadata map[string]interface{}, unknown []string, body []byte) ([]byte, error) {
+	if !set {
+		return body, nil
 	}
 
-	// Apply front-matter (highest priority)
-	for k, v := range frontMatter {
-		if v == nil {
-			delete(result, k)
-		} else {
-			result[k] = v
-		}
-	}
-
-	return result
-}
-
-// renderCursorRule renders a Cursor rule with metadata as front-matter.
-func renderCursorRule(metadata map[string]interface{}, body []byte) []byte {
 	var buf bytes.Buffer
-
-	// Write front-matter if there's metadata
-	if len(metadata) > 0 {
-		buf.WriteString("---\n")
-		// Marshal metadata to YAML
-		yamlBytes, _ := yaml.Marshal(metadata)
-		buf.Write(yamlBytes)
-		buf.WriteString("---\n\n")
+	buf.WriteString("---\n")
+	if r.Description != "" {
+		description, _ := yaml.Marshal(r.Description)
+		buf.WriteString("description: ")
+		buf.Write(description)
+	}
+	if len(r.Globs) > 0 {
+		buf.WriteString("globs: " + strings.Join(r.Globs, ",") + "\n")
+	}
+	buf.WriteString("alwaysApply: " + strconv.FormatBool(r.AlwaysApply) + "\n")
+	for _, key := range unknown {
+		field, err := yaml.Marshal(map[string]interface{}{key: metadata[key]})
+		if err != nil {
+			return nil, fmt.Errorf("render field %s: %w", key, err)
+		}
+		buf.Write(field)
 	}
-
-	// Write body
+	buf.WriteString("---\n\n")
 	buf.Write(body)
-
-	return buf.Bytes()
-}
-
-// isMarkdownFile checks if a file is a markdown file.
-func isMarkdownFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".md" || ext == ".markdown" || ext == ".mdc"
+	return buf.Bytes(), nil
 }
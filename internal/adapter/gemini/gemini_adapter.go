@@ -0,0 +1,156 @@
+package gemini
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+)
+
+const (
+	contextFile = "GEMINI.md"
+	commandsDir = ".gemini/commands"
+)
+
+// positionalArg matches the $1 to $9 placeholders of Claude commands
+var positionalArg = regexp.MustCompile(`\$[1-9]\b`)
+
+// Adapter implements the AgentAdapter interface for Gemini CLI.
+//
+// Rules from every pack are composed into the managed section of GEMINI.md,
+// and command-style prompts (kind: command) become
+// .gemini/commands/<name>.toml custom commands.
+type Adapter struct {
+	naming string // Naming strategy of command files
+}
+
+// NewAdapter creates a new Gemini CLI adapter using the given naming strategy.
+func NewAdapter(naming string) adapter.AgentAdapter {
+	return &Adapter{naming: naming}
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return "gemini"
+}
+
+// Detect checks if Gemini CLI is present/configured.
+func (a *Adapter) Detect() bool {
+	for _, path := range []string{contextFile, ".gemini"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetDir returns the repository root, GEMINI.md lives next to the
+// .gemini directory.
+func (a *Adapter) TargetDir(scope adapter.Scope) string {
+	return "."
+}
+
+// Render converts a prompt pack into GEMINI.md section fragments and TOML
+// commands. Prompts with other kinds are left to other adapters.
+func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
+	dir := adapter.PromptsDir(pack.Path, "rules", "commands")
+	if dir == "" {
+		return nil, nil
+	}
+
+	prompts, err := adapter.Prompts(pack, dir, a.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var files []adapter.RenderedFile
+	for _, prompt := range prompts {
+		file := adapter.RenderedFile{SourcePath: prompt.SourcePath}
+		switch prompt.Kind {
+		case "", "rule":
+			file.Path = contextFile
+			file.Content = prompt.Body
+			file.Fragment = true
+			file.Section = true
+		case "command":
+			name := strings.TrimSuffix(adapter.OutputName(a.naming, pack, dir, prompt.Path), filepath.Ext(prompt.Path))
+			description, _ := prompt.Meta["description"].(string)
+			file.Path = filepath.Join(commandsDir, name+".toml")
+			file.Content = RenderCommand(description, prompt.Body)
+			if positionalArg.Match(prompt.Body) {
+				pack.Warnings.Add("%s: Gemini CLI has no positional arguments, $1-$9 are replaced with all of them ({{args}})", prompt.SourcePath)
+			}
+		default:
+			continue
+		}
+		file.Hash = adapter.HashContent(file.Content)
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Compose builds the managed section of GEMINI.md from its rules.
+func (a *Adapter) Compose(path string, fragments []adapter.Fragment) ([]byte, error) {
+	return adapter.JoinFragments(fragments), nil
+}
+
+// Verify checks that rendered files match expected hashes.
+func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
+	return adapter.VerifyHashes(files, mode)
+}
+
+// GitignorePatterns returns the patterns covering rendered commands. GEMINI.md
+// holds hand-written content and stays under version control.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	if a.naming == adapter.NamingNested {
+		return []string{commandsDir + "/**/*.toml"}
+	}
+	return []string{commandsDir + "/*.toml"}
+}
+
+// RenderCommand converts a markdown command into the Gemini CLI TOML command
+// format. The $ARGUMENTS placeholder used by Claude commands becomes {{args}},
+// and so do the positional $1 to $9, which Gemini CLI does not support.
+func RenderCommand(description string, body []byte) []byte {
+	prompt := strings.ReplaceAll(string(body), "$ARGUMENTS", "{{args}}")
+	prompt = positionalArg.ReplaceAllString(prompt, "{{args}}")
+
+	var buf strings.Builder
+	if description != "" {
+		buf.WriteString("description = " + quote(description) + "\n")
+	}
+	buf.WriteString(`prompt = """` + "\n")
+	buf.WriteString(escape(prompt))
+	buf.WriteString(`"""` + "\n")
+	return []byte(buf.String())
+}
+
+// quote returns s as a TOML basic string
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(escape(s), "\n", `\n`) + `"`
+}
+
+// escape escapes s for a TOML basic string, single or multi-line
+func escape(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			buf.WriteString(`\\`)
+		case r == '"':
+			buf.WriteString(`\"`)
+		case r == '\n' || r == '\t':
+			buf.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&buf, `\u%04X`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
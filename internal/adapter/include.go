@@ -0,0 +1,118 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// includePattern matches include directives such as {{> partials/tone.md}}
+var includePattern = regexp.MustCompile(`\{\{>\s*([^\s{}]+)\s*\}\}`)
+
+// includer expands the includes of one prompt. Includes name a file relative
+// to the pack root, or name:path for a file of another named source.
+type includer struct {
+	packPath string
+	packs    map[string]string // Pack roots of other named sources
+	included map[string]bool   // Every file pulled in, as written in the lock
+}
+
+// ExpandIncludes resolves the include: front-matter list and the {{> path}}
+// directives of a prompt body. Listed files are placed before the body and
+// directives are replaced by the file they name, both without their
+// front-matter and recursively expanded. It returns the expanded body and
+// the files that contributed to it, sorted.
+func ExpandIncludes(packPath, sourcePath string, meta map[string]interface{}, body []byte, packs map[string]string) ([]byte, []string, error) {
+	inc := &includer{packPath: packPath, packs: packs, included: make(map[string]bool)}
+	expanded, err := inc.expand("", sourcePath, meta, body, []string{sourcePath})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]string, 0, len(inc.included))
+	for file := range inc.included {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return expanded, files, nil
+}
+
+// expand resolves the includes of a file of the named pack, "" being the
+// prompt's own pack. stack holds the files being expanded to detect cycles.
+func (inc *includer) expand(pack, path string, meta map[string]interface{}, body []byte, stack []string) ([]byte, error) {
+	var parts [][]byte
+	for _, ref := range StringList(meta["include"]) {
+		content, err := inc.include(pack, path, ref, stack)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, content)
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, match := range includePattern.FindAllSubmatchIndex(body, -1) {
+		content, err := inc.include(pack, path, string(body[match[2]:match[3]]), stack)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(body[last:match[0]])
+		buf.Write(content)
+		last = match[1]
+	}
+	buf.Write(body[last:])
+
+	if len(parts) == 0 {
+		return buf.Bytes(), nil
+	}
+	return bytes.Join(append(parts, buf.Bytes()), []byte("\n\n")), nil
+}
+
+// include reads and expands the file named by ref from a file of pack
+func (inc *includer) include(pack, from, ref string, stack []string) ([]byte, error) {
+	name, path, crossSource := strings.Cut(ref, ":")
+	if !crossSource {
+		name, path = pack, ref
+	}
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return nil, fmt.Errorf("%s: include %s must be a path inside the pack", from, ref)
+	}
+
+	root := inc.packPath
+	if name != "" {
+		var ok bool
+		if root, ok = inc.packs[name]; !ok {
+			return nil, fmt.Errorf("%s: include %s names unknown source %q", from, ref, name)
+		}
+	}
+
+	key := filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+	if name != "" {
+		key = name + ":" + key
+	}
+	for _, parent := range stack {
+		if parent == key {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, key), " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: include %s: %w", from, ref, err)
+	}
+	frontMatter, body, err := ParseFrontMatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse front-matter in %s: %w", key, err)
+	}
+	inc.included[key] = true
+
+	expanded, err := inc.expand(name, key, frontMatter, body, append(stack[:len(stack):len(stack)], key))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(expanded, "\r\n"), nil
+}
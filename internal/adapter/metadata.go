@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metadata represents the structure of metadata.yaml
+type Metadata struct {
+	Defaults map[string]interface{}            `yaml:"defaults"`
+	Files    map[string]map[string]interface{} `yaml:"files"`
+
+	// Vars are the pack's default template variables
+	Vars map[string]interface{} `yaml:"vars"`
+}
+
+// LoadMetadataFile loads and parses a metadata.yaml file.
+func LoadMetadataFile(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Return empty metadata if file doesn't exist
+			return &Metadata{
+				Defaults: make(map[string]interface{}),
+				Files:    make(map[string]map[string]interface{}),
+			}, nil
+		}
+		return nil, err
+	}
+
+	var metadata Metadata
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("parse metadata.yaml: %w", err)
+	}
+
+	// Initialize maps if nil
+	if metadata.Defaults == nil {
+		metadata.Defaults = make(map[string]interface{})
+	}
+	if metadata.Files == nil {
+		metadata.Files = make(map[string]map[string]interface{})
+	}
+
+	return &metadata, nil
+}
+
+// ParseFrontMatter extracts YAML front-matter from markdown content.
+func ParseFrontMatter(content []byte) (map[string]interface{}, []byte, error) {
+	// Check if content starts with ---
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		// No front-matter
+		return make(map[string]interface{}), content, nil
+	}
+
+	// Find the closing ---
+	lines := bytes.Split(content, []byte("\n"))
+	var endIndex int
+	for i := 1; i < len(lines); i++ {
+		line := bytes.TrimSpace(lines[i])
+		if bytes.Equal(line, []byte("---")) {
+			endIndex = i
+			break
+		}
+	}
+
+	if endIndex == 0 {
+		// No closing ---, treat as no front-matter
+		return make(map[string]interface{}), content, nil
+	}
+
+	// Extract front-matter
+	frontMatterBytes := bytes.Join(lines[1:endIndex], []byte("\n"))
+
+	// Parse YAML
+	var frontMatter map[string]interface{}
+	if err := yaml.Unmarshal(frontMatterBytes, &frontMatter); err != nil {
+		return nil, nil, fmt.Errorf("parse YAML front-matter: %w", err)
+	}
+
+	if frontMatter == nil {
+		frontMatter = make(map[string]interface{})
+	}
+
+	// Extract body (everything after the closing ---)
+	bodyLines := lines[endIndex+1:]
+	body := bytes.Join(bodyLines, []byte("\n"))
+	body = bytes.TrimLeft(body, "\n\r")
+
+	return frontMatter, body, nil
+}
+
+// MergeMetadata merges metadata from three sources with proper precedence.
+func MergeMetadata(defaults, fileOverride, frontMatter map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	// Start with defaults
+	for k, v := range defaults {
+		result[k] = v
+	}
+
+	// Apply file overrides
+	for k, v := range fileOverride {
+		if v == nil {
+			// nil means remove the field
+			delete(result, k)
+		} else {
+			result[k] = v
+		}
+	}
+
+	// Apply front-matter (highest priority)
+	for k, v := range frontMatter {
+		if v == nil {
+			delete(result, k)
+		} else {
+			result[k] = v
+		}
+	}
+
+	return result
+}
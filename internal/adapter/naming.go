@@ -0,0 +1,57 @@
+package adapter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Naming strategies for the files an adapter renders from prompts
+const (
+	NamingFlat   = "flat"   // Base name of the prompt, the default
+	NamingNested = "nested" // <source>/<path below the prompts directory>
+	NamingPath   = "path"   // Path below the prompts directory joined with dashes
+)
+
+// ParseNaming reads the naming setting of an adapters.<name> block
+func ParseNaming(settings map[string]interface{}) (string, error) {
+	naming, _ := settings["naming"].(string)
+	switch naming {
+	case "":
+		return NamingFlat, nil
+	case NamingFlat, NamingNested, NamingPath:
+		return naming, nil
+	}
+	return "", fmt.Errorf("invalid naming %q: must be %s, %s or %s", naming, NamingFlat, NamingNested, NamingPath)
+}
+
+// OutputName returns the path, relative to the adapter's output directory,
+// of the file rendered from the prompt at path below dir
+func OutputName(naming string, pack PromptPack, dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+
+	switch naming {
+	case NamingNested:
+		return filepath.Join(SourceName(pack), rel)
+	case NamingPath:
+		return strings.ReplaceAll(rel, string(filepath.Separator), "-")
+	default:
+		return filepath.Base(rel)
+	}
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SourceName returns the directory name of a pack in nested layouts: the
+// trusted source name, or the repository name of its URL
+func SourceName(pack PromptPack) string {
+	name := pack.Source
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(strings.TrimRight(pack.Name, "/")), ".git")
+	}
+	return strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-")
+}
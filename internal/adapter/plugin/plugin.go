@@ -0,0 +1,249 @@
+// Package plugin runs adapters shipped as separate executables.
+//
+// An adapter plugin is an executable named prompt-sync-adapter-<name> on
+// PATH. It is run once per request with a JSON request on stdin and must
+// print a JSON response on stdout:
+//
+//	describe   {"command":"describe","config":{...}}
+//	        -> {"target_dir":".agent/rules"}
+//	discover   {"command":"discover","config":{...},"pack":{...}}
+//	        -> {"files":[{"source_path":"prompts/style.md"}]}
+//	render     {"command":"render","config":{...},"pack":{...},"scope":"project",
+//	            "files":[{"source_path":"prompts/style.md","content":"..."}]}
+//	        -> {"files":[{"path":"style.md","source_path":"prompts/style.md","content":"..."}]}
+//	gitignore  {"command":"gitignore","config":{...},"packs":[{...}]}
+//	        -> {"patterns":[".agent/rules/"]}
+//
+// "config" is the plugin's adapters.<name> block from the Promptsfile and a
+// pack is {"name","path","source","ref","prefix"}. The content of a prompt
+// has its includes expanded and its template applied. Rendered paths are
+// relative to the target directory. A non-zero exit status or a response
+// with a non-empty "error" fails the request.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+)
+
+// ExecutablePrefix is prepended to the adapter name to find its plugin
+const ExecutablePrefix = "prompt-sync-adapter-"
+
+// Find returns the path of the plugin executable for an adapter
+func Find(name string) (string, error) {
+	return exec.LookPath(ExecutablePrefix + name)
+}
+
+// Adapter implements the AgentAdapter interface by running a plugin.
+type Adapter struct {
+	name      string
+	path      string
+	config    map[string]interface{}
+	targetDir string
+}
+
+// NewAdapter creates an adapter backed by the plugin executable at path. The
+// plugin is asked for its target directory up front.
+func NewAdapter(name, path string, config map[string]interface{}) (adapter.AgentAdapter, error) {
+	a := &Adapter{name: name, path: path, config: config}
+
+	resp, err := a.call(request{Command: "describe"})
+	if err != nil {
+		return nil, err
+	}
+	if resp.TargetDir == "" || !filepath.IsLocal(resp.TargetDir) {
+		return nil, fmt.Errorf("adapter plugin %s: invalid target directory %q", name, resp.TargetDir)
+	}
+	a.targetDir = filepath.Clean(resp.TargetDir)
+
+	return a, nil
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return a.name
+}
+
+// Detect reports that the agent is available, since its plugin is installed.
+func (a *Adapter) Detect() bool {
+	return true
+}
+
+// TargetDir returns the directory the plugin renders into.
+func (a *Adapter) TargetDir(scope adapter.Scope) string {
+	return a.targetDir
+}
+
+// Render asks the plugin which files of the pack it handles, then renders
+// them. Prompts are sent as loaded by the installer, with includes expanded
+// and templates applied; those whose targets leave out the plugin are not
+// sent to it.
+func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
+	info := newPack(pack)
+	discovered, err := a.call(request{Command: "discover", Pack: &info})
+	if err != nil {
+		return nil, err
+	}
+
+	prompts := pack.Prompts
+	if prompts == nil {
+		if prompts, err = adapter.LoadPrompts(pack.Path, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	inputs := make([]file, 0, len(discovered.Files))
+	for _, f := range discovered.Files {
+		if !filepath.IsLocal(f.SourcePath) {
+			return nil, fmt.Errorf("adapter plugin %s: invalid source path %q", a.name, f.SourcePath)
+		}
+		content, rendered, err := a.content(pack, prompts, f.SourcePath)
+		if err != nil {
+			return nil, err
+		}
+		if !rendered {
+			continue
+		}
+		inputs = append(inputs, file{SourcePath: f.SourcePath, Content: string(content)})
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	rendered, err := a.call(request{Command: "render", Pack: &info, Scope: string(scope), Files: inputs})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]adapter.RenderedFile, 0, len(rendered.Files))
+	for _, f := range rendered.Files {
+		if f.Path == "" || !filepath.IsLocal(f.Path) {
+			return nil, fmt.Errorf("adapter plugin %s: invalid output path %q", a.name, f.Path)
+		}
+		content := []byte(f.Content)
+		files = append(files, adapter.RenderedFile{
+			Path:       filepath.Clean(f.Path),
+			SourcePath: f.SourcePath,
+			Content:    content,
+			Hash:       adapter.HashContent(content),
+		})
+	}
+	return files, nil
+}
+
+// content returns what the plugin is sent for a discovered file: the prompt
+// with its includes expanded and its template applied, or the file as
+// written when it is not a prompt. rendered is false for prompts whose
+// targets leave out the plugin.
+func (a *Adapter) content(pack adapter.PromptPack, prompts map[string]adapter.Prompt, sourcePath string) (content []byte, rendered bool, err error) {
+	prompt, ok := prompts[filepath.Clean(sourcePath)]
+	if !ok {
+		content, err := os.ReadFile(filepath.Join(pack.Path, sourcePath))
+		if err != nil {
+			return nil, false, fmt.Errorf("read file %s: %w", sourcePath, err)
+		}
+		return content, true, nil
+	}
+
+	if !adapter.Route(prompt.Meta, a.name).Rendered {
+		return nil, false, nil
+	}
+	if prompt, err = adapter.ApplyTemplate(pack, prompt, a.name); err != nil {
+		return nil, false, err
+	}
+	return prompt.Content, true, nil
+}
+
+// Verify checks that rendered files match expected hashes.
+func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
+	return adapter.VerifyHashes(files, mode)
+}
+
+// GitignorePatterns asks the plugin for its .gitignore patterns. When the
+// plugin fails, the whole target directory is ignored.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	req := request{Command: "gitignore", Packs: make([]pack, 0, len(packs))}
+	for _, p := range packs {
+		req.Packs = append(req.Packs, newPack(p))
+	}
+
+	resp, err := a.call(req)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return []string{a.targetDir + "/"}
+	}
+	return resp.Patterns
+}
+
+// request is the JSON document written to the plugin's stdin
+type request struct {
+	Command string                 `json:"command"`
+	Config  map[string]interface{} `json:"config"`
+	Pack    *pack                  `json:"pack,omitempty"`
+	Packs   []pack                 `json:"packs,omitempty"`
+	Scope   string                 `json:"scope,omitempty"`
+	Files   []file                 `json:"files,omitempty"`
+}
+
+// response is the JSON document the plugin prints on stdout
+type response struct {
+	TargetDir string   `json:"target_dir"`
+	Files     []file   `json:"files"`
+	Patterns  []string `json:"patterns"`
+	Error     string   `json:"error"`
+}
+
+type pack struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Ref    string `json:"ref"`
+	Prefix string `json:"prefix"`
+}
+
+type file struct {
+	Path       string `json:"path,omitempty"`
+	SourcePath string `json:"source_path"`
+	Content    string `json:"content,omitempty"`
+}
+
+func newPack(p adapter.PromptPack) pack {
+	return pack{Name: p.Name, Path: p.Path, Source: p.Source, Ref: p.Ref, Prefix: p.Prefix}
+}
+
+// call runs the plugin with a single request
+func (a *Adapter) call(req request) (*response, error) {
+	req.Config = a.config
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("adapter plugin %s: encode %s request: %w", a.name, req.Command, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(a.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("adapter plugin %s: %s failed: %w: %s", a.name, req.Command, err, msg)
+		}
+		return nil, fmt.Errorf("adapter plugin %s: %s failed: %w", a.name, req.Command, err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("adapter plugin %s: invalid %s response: %w", a.name, req.Command, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("adapter plugin %s: %s failed: %s", a.name, req.Command, resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,239 @@
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// promptDirs are the directories of a pack that adapters read prompts from
+var promptDirs = []string{"prompts", "rules", "commands"}
+
+// SkillFile is the file that makes a directory below the prompts directory a
+// skill
+const SkillFile = "SKILL.md"
+
+// Prompt is a markdown file of a pack with its metadata resolved
+type Prompt struct {
+	Path       string                 // Absolute path
+	SourcePath string                 // Path relative to the pack root
+	Meta       map[string]interface{} // metadata.yaml defaults and overrides merged with front-matter
+	Content    []byte                 // Raw file content
+	Body       []byte                 // Content without front-matter
+	Kind       string                 // Kind the prompt is rendered as, resolved for one adapter by Prompts
+	Vars       map[string]interface{} // Template variable defaults from metadata.yaml
+	Includes   []string               // Files included into the body, name:path for other sources
+}
+
+// LoadPrompts parses the front-matter of every prompt of the pack at
+// packPath and expands its includes; packs holds the pack roots of the other
+// named sources that prompts may include from. Prompts are keyed by their
+// path relative to the pack root.
+func LoadPrompts(packPath string, packs map[string]string) (map[string]Prompt, error) {
+	prompts := make(map[string]Prompt)
+	for _, name := range promptDirs {
+		dir := filepath.Join(packPath, name)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		metadata, err := LoadMetadataFile(filepath.Join(dir, "metadata.yaml"))
+		if err != nil {
+			return nil, err
+		}
+
+		paths, err := MarkdownFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read file %s: %w", path, err)
+			}
+
+			frontMatter, body, err := ParseFrontMatter(content)
+			if err != nil {
+				return nil, fmt.Errorf("parse front-matter in %s: %w", path, err)
+			}
+
+			sourcePath, err := filepath.Rel(packPath, path)
+			if err != nil {
+				return nil, err
+			}
+
+			meta := MergeMetadata(metadata.Defaults, metadata.Files[filepath.Base(path)], frontMatter)
+			expanded, includes, err := ExpandIncludes(packPath, filepath.ToSlash(sourcePath), meta, body, packs)
+			if err != nil {
+				return nil, err
+			}
+			// The body is the tail of the content, after the front-matter
+			content = append(content[:len(content)-len(body):len(content)-len(body)], expanded...)
+
+			prompts[sourcePath] = Prompt{
+				Path:       path,
+				SourcePath: sourcePath,
+				Meta:       meta,
+				Content:    content,
+				Body:       expanded,
+				Vars:       metadata.Vars,
+				Includes:   includes,
+			}
+		}
+	}
+	return prompts, nil
+}
+
+// Prompts returns the prompts below dir that the named adapter renders, in
+// lexical order and with Kind resolved for that adapter. Files of a skill
+// directory without a kind of their own are skills. Bodies are run
+// through the template engine when the prompt sets template: true.
+// Prompts parsed by the installer are reused, otherwise the pack is parsed.
+func Prompts(pack PromptPack, dir, adapterName string) ([]Prompt, error) {
+	all := pack.Prompts
+	if all == nil {
+		var err error
+		if all, err = LoadPrompts(pack.Path, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	paths, err := MarkdownFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	skillDirs := SkillDirs(dir, paths)
+
+	var prompts []Prompt
+	for _, path := range paths {
+		sourcePath, err := filepath.Rel(pack.Path, path)
+		if err != nil {
+			return nil, err
+		}
+		prompt, ok := all[sourcePath]
+		if !ok {
+			continue
+		}
+
+		routing := Route(prompt.Meta, adapterName)
+		if !routing.Rendered {
+			continue
+		}
+		prompt.Kind = routing.Kind
+		if prompt.Kind == "" && InSkillDir(skillDirs, filepath.Dir(path)) {
+			prompt.Kind = "skill"
+		}
+
+		if prompt, err = ApplyTemplate(pack, prompt, adapterName); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, nil
+}
+
+// SkillDirs returns the directories below dir holding a SKILL.md, given the
+// markdown files of dir
+func SkillDirs(dir string, paths []string) map[string]bool {
+	skillDirs := make(map[string]bool)
+	for _, path := range paths {
+		if IsSkillFile(path) && filepath.Dir(path) != dir {
+			skillDirs[filepath.Dir(path)] = true
+		}
+	}
+	return skillDirs
+}
+
+// IsSkillFile reports whether path is named SKILL.md
+func IsSkillFile(path string) bool {
+	return strings.EqualFold(filepath.Base(path), SkillFile)
+}
+
+// InSkillDir reports whether dir is a skill directory or below one
+func InSkillDir(skillDirs map[string]bool, dir string) bool {
+	for skillDir := range skillDirs {
+		if dir == skillDir || strings.HasPrefix(dir, skillDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTemplate runs the body of a prompt through the template engine for
+// the named adapter when the prompt is a template, updating both its Body
+// and its Content. Warnings are added to the pack's.
+func ApplyTemplate(pack PromptPack, prompt Prompt, adapterName string) (Prompt, error) {
+	if !templated(prompt) {
+		return prompt, nil
+	}
+
+	vars := MergeMetadata(prompt.Vars, pack.Vars, nil)
+	body, warnings, err := RenderTemplate(prompt.SourcePath, prompt.Body, vars, adapterName, pack.StrictVars)
+	if err != nil {
+		return Prompt{}, err
+	}
+	for _, warning := range warnings {
+		pack.Warnings.Add("%s", warning)
+	}
+	// The body is the tail of the content, after the front-matter
+	frontMatter := prompt.Content[:len(prompt.Content)-len(prompt.Body)]
+	prompt.Content = append(append([]byte{}, frontMatter...), body...)
+	prompt.Body = body
+	return prompt, nil
+}
+
+// templated reports whether a prompt's body is a template. Templating is
+// opt-in, through template: true in the front-matter or in the defaults of
+// metadata.yaml, so that prompts quoting {{ }} syntax, such as GitHub Actions
+// expressions, install unchanged whatever vars are declared.
+func templated(prompt Prompt) bool {
+	return IsTrue(prompt.Meta["template"])
+}
+
+// Routing is the decision whether an adapter renders a prompt
+type Routing struct {
+	Rendered bool
+	Kind     string // Kind to render the prompt as, empty when not declared
+	Target   string // Matching targets entry, empty when the prompt has no targets
+	Reason   string
+}
+
+// kindAliases maps the short kinds usable in sub-targets such as claude.cmd
+var kindAliases = map[string]string{
+	"cmd":      "command",
+	"commands": "command",
+	"agents":   "agent",
+	"skills":   "skill",
+	"rules":    "rule",
+}
+
+// Route decides whether the named adapter renders a prompt with the given
+// metadata. A prompt without targets: is rendered by every adapter. An entry
+// such as claude.cmd both selects the adapter and overrides the prompt's kind
+// for it.
+func Route(meta map[string]interface{}, adapterName string) Routing {
+	kind, _ := meta["kind"].(string)
+
+	targets := StringList(meta["targets"])
+	if len(targets) == 0 {
+		return Routing{Rendered: true, Kind: kind, Reason: "no targets, rendered by every adapter"}
+	}
+
+	for _, target := range targets {
+		name, subKind, _ := strings.Cut(target, ".")
+		if name != adapterName {
+			continue
+		}
+		if subKind != "" {
+			kind = subKind
+			if alias, ok := kindAliases[subKind]; ok {
+				kind = alias
+			}
+		}
+		return Routing{Rendered: true, Kind: kind, Target: target, Reason: "listed in targets as " + target}
+	}
+
+	return Routing{Kind: kind, Reason: "not in targets: " + strings.Join(targets, ", ")}
+}
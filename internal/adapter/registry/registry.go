@@ -0,0 +1,103 @@
+// Package registry maps adapter names to the adapters that implement them.
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/adapter/agents"
+	"github.com/kovyrin/prompt-sync/internal/adapter/claude"
+	"github.com/kovyrin/prompt-sync/internal/adapter/copilot"
+	"github.com/kovyrin/prompt-sync/internal/adapter/cursor"
+	"github.com/kovyrin/prompt-sync/internal/adapter/gemini"
+	"github.com/kovyrin/prompt-sync/internal/adapter/plugin"
+	"github.com/kovyrin/prompt-sync/internal/adapter/windsurf"
+)
+
+// Factory creates an adapter from the settings of its adapters.<name> block
+type Factory func(settings map[string]interface{}) (adapter.AgentAdapter, error)
+
+// Registry holds the built-in adapters. Adapters that are not registered are
+// looked up as plugins on PATH.
+type Registry struct {
+	factories map[string]Factory
+}
+
+// New creates an empty registry
+func New() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Default returns a registry with the built-in adapters
+func Default() *Registry {
+	r := New()
+	r.Register("cursor", withNaming(cursor.NewAdapter))
+	r.Register("claude", func(settings map[string]interface{}) (adapter.AgentAdapter, error) {
+		naming, err := adapter.ParseNaming(settings)
+		if err != nil {
+			return nil, err
+		}
+		prefix, _ := settings["prefix"].(string)
+		return claude.NewAdapter(prefix, naming), nil
+	})
+	r.Register("agents", func(map[string]interface{}) (adapter.AgentAdapter, error) {
+		return agents.NewAdapter(), nil
+	})
+	r.Register("copilot", withNaming(copilot.NewAdapter))
+	r.Register("gemini", withNaming(gemini.NewAdapter))
+	r.Register("windsurf", withNaming(windsurf.NewAdapter))
+	return r
+}
+
+// withNaming makes a factory for an adapter that only takes a naming strategy
+func withNaming(newAdapter func(naming string) adapter.AgentAdapter) Factory {
+	return func(settings map[string]interface{}) (adapter.AgentAdapter, error) {
+		naming, err := adapter.ParseNaming(settings)
+		if err != nil {
+			return nil, err
+		}
+		return newAdapter(naming), nil
+	}
+}
+
+// Register adds or replaces the factory for an adapter name
+func (r *Registry) Register(name string, factory Factory) {
+	r.factories[name] = factory
+}
+
+// Build creates the enabled adapters, given as settings keyed by adapter
+// name, in a stable order
+func (r *Registry) Build(enabled map[string]map[string]interface{}) ([]adapter.AgentAdapter, error) {
+	names := make([]string, 0, len(enabled))
+	for name := range enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	adapters := make([]adapter.AgentAdapter, 0, len(names))
+	for _, name := range names {
+		a, err := r.build(name, enabled[name])
+		if err != nil {
+			return nil, err
+		}
+		adapters = append(adapters, a)
+	}
+	return adapters, nil
+}
+
+func (r *Registry) build(name string, settings map[string]interface{}) (adapter.AgentAdapter, error) {
+	if factory, ok := r.factories[name]; ok {
+		a, err := factory(settings)
+		if err != nil {
+			return nil, fmt.Errorf("adapter %s: %w", name, err)
+		}
+		return a, nil
+	}
+
+	path, err := plugin.Find(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown adapter %q: no built-in adapter and no %s%s on PATH", name, plugin.ExecutablePrefix, name)
+	}
+	return plugin.NewAdapter(name, path, settings)
+}
@@ -0,0 +1,118 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// RenderTemplate runs a prompt body through text/template with the given
+// variables as data. Only the standard template functions and adapter,
+// which returns the name of the adapter rendering the prompt, are
+// available. Variables the body uses but nobody defined render empty with a
+// warning, which is returned, or fail when strict is set. Bodies that are
+// not valid templates fail when strict is set and are otherwise returned
+// unchanged with a warning.
+func RenderTemplate(name string, body []byte, vars map[string]interface{}, adapterName string, strict bool) ([]byte, []string, error) {
+	if !bytes.Contains(body, []byte("{{")) {
+		return body, nil, nil
+	}
+
+	funcs := template.FuncMap{"adapter": func() string { return adapterName }}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(body))
+	if err != nil {
+		if strict {
+			return nil, nil, err
+		}
+		return body, []string{fmt.Sprintf("%v; installed without templating", err)}, nil
+	}
+
+	data := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		data[k] = v
+	}
+	var warnings []string
+	for _, variable := range templateVariables(tmpl) {
+		if _, ok := data[variable]; ok {
+			continue
+		}
+		if strict {
+			return nil, nil, fmt.Errorf("%s: undefined template variable %q", name, variable)
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: undefined template variable %q rendered empty for %s", name, variable, adapterName))
+		data[variable] = ""
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if strict {
+			return nil, nil, err
+		}
+		return body, append(warnings, fmt.Sprintf("%v; installed without templating", err)), nil
+	}
+	return buf.Bytes(), warnings, nil
+}
+
+// templateVariables returns the top-level variables a template reads, such
+// as project in {{.project}} or {{$.project.name}}, sorted by name
+func templateVariables(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	var walk func(node parse.Node, topLevel bool)
+	walk = func(node parse.Node, topLevel bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, topLevel)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, topLevel)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg, topLevel)
+				}
+			}
+		case *parse.ChainNode:
+			walk(n.Node, topLevel)
+		case *parse.FieldNode:
+			// Inside range and with the dot is no longer the variables
+			if topLevel {
+				seen[n.Ident[0]] = true
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				seen[n.Ident[1]] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, topLevel)
+			walk(n.List, topLevel)
+			walk(n.ElseList, topLevel)
+		case *parse.RangeNode:
+			walk(n.Pipe, topLevel)
+			walk(n.List, false)
+			walk(n.ElseList, topLevel)
+		case *parse.WithNode:
+			walk(n.Pipe, topLevel)
+			walk(n.List, false)
+			walk(n.ElseList, topLevel)
+		case *parse.TemplateNode:
+			walk(n.Pipe, topLevel)
+		}
+	}
+	walk(tmpl.Tree.Root, true)
+
+	variables := make([]string, 0, len(seen))
+	for name := range seen {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
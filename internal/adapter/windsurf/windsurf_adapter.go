@@ -0,0 +1,177 @@
+package windsurf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+)
+
+// MaxRuleSize is the number of characters Windsurf reads from a rule file
+const MaxRuleSize = 12000
+
+// ruleExt is the extension of the rule files Windsurf loads
+const ruleExt = ".md"
+
+// Windsurf rule triggers
+const (
+	TriggerAlwaysOn      = "always_on"
+	TriggerGlob          = "glob"
+	TriggerModelDecision = "model_decision"
+	TriggerManual        = "manual"
+)
+
+// Adapter implements the AgentAdapter interface for Windsurf.
+//
+// Rules become .windsurf/rules/<name>.md with a trigger derived from the
+// Cursor-style fields: alwaysApply gives always_on, globs gives glob, a
+// description gives model_decision and anything else is manual.
+type Adapter struct {
+	naming string // Naming strategy of rule files
+}
+
+// NewAdapter creates a new Windsurf adapter using the given naming strategy.
+func NewAdapter(naming string) adapter.AgentAdapter {
+	return &Adapter{naming: naming}
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return "windsurf"
+}
+
+// Detect checks if Windsurf is present/configured.
+func (a *Adapter) Detect() bool {
+	_, err := os.Stat(".windsurf")
+	return err == nil
+}
+
+// TargetDir returns the directory where Windsurf rules should be rendered.
+func (a *Adapter) TargetDir(scope adapter.Scope) string {
+	return ".windsurf/rules"
+}
+
+// Render converts the rules of a prompt pack into Windsurf rule files.
+// Prompts with a kind other than rule are left to other adapters.
+func (a *Adapter) Render(pack adapter.PromptPack, scope adapter.Scope) ([]adapter.RenderedFile, error) {
+	dir := adapter.PromptsDir(pack.Path, "rules")
+	if dir == "" {
+		return nil, nil
+	}
+
+	prompts, err := adapter.Prompts(pack, dir, a.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var files []adapter.RenderedFile
+	for _, prompt := range prompts {
+		if prompt.Kind != "" && prompt.Kind != "rule" {
+			continue
+		}
+		sourcePath := prompt.SourcePath
+
+		rendered, err := renderRule(prompt.Meta, prompt.Body)
+		if err != nil {
+			return nil, fmt.Errorf("render %s: %w", sourcePath, err)
+		}
+		if size := utf8.RuneCount(rendered); size > MaxRuleSize {
+			return nil, fmt.Errorf("%s renders to %d characters, over the Windsurf limit of %d per rule file: split it into smaller rules", sourcePath, size, MaxRuleSize)
+		}
+
+		// Rules are written as .md files whatever the source extension
+		name := adapter.OutputName(a.naming, pack, dir, prompt.Path)
+		files = append(files, adapter.RenderedFile{
+			Path:       strings.TrimSuffix(name, filepath.Ext(name)) + ruleExt,
+			SourcePath: sourcePath,
+			Content:    rendered,
+			Hash:       adapter.HashContent(rendered),
+		})
+	}
+
+	return files, nil
+}
+
+// Verify checks that rendered files match expected hashes.
+func (a *Adapter) Verify(files []adapter.RenderedFile, mode adapter.Strictness) error {
+	return adapter.VerifyHashes(files, mode)
+}
+
+// ScanDirs returns the directories checked for duplicate basenames. Nested
+// layouts keep files with the same name apart, so they are not checked.
+func (a *Adapter) ScanDirs() []string {
+	if a.naming == adapter.NamingNested {
+		return nil
+	}
+	return []string{a.TargetDir(adapter.ScopeProject)}
+}
+
+// GitignorePatterns returns the patterns covering rendered Windsurf rules.
+func (a *Adapter) GitignorePatterns(packs []adapter.PromptPack) []string {
+	return []string{a.TargetDir(adapter.ScopeProject) + "/"}
+}
+
+// Trigger returns the Windsurf trigger for a prompt's merged metadata. An
+// explicit trigger field wins over the Cursor-style fields.
+func Trigger(meta map[string]interface{}) (string, error) {
+	if trigger, ok := meta["trigger"].(string); ok {
+		switch trigger {
+		case TriggerAlwaysOn, TriggerGlob, TriggerModelDecision, TriggerManual:
+			return trigger, nil
+		}
+		return "", fmt.Errorf("invalid trigger %q: must be %s, %s, %s or %s", trigger, TriggerAlwaysOn, TriggerGlob, TriggerModelDecision, TriggerManual)
+	}
+
+	switch {
+	case adapter.IsTrue(meta["alwaysApply"]):
+		return TriggerAlwaysOn, nil
+	case len(adapter.StringList(meta["globs"])) > 0:
+		return TriggerGlob, nil
+	case meta["description"] != nil && meta["description"] != "":
+		return TriggerModelDecision, nil
+	default:
+		return TriggerManual, nil
+	}
+}
+
+// ruleFrontMatter is the front-matter of a Windsurf rule
+type ruleFrontMatter struct {
+	Trigger     string `yaml:"trigger"`
+	Description string `yaml:"description,omitempty"`
+	Globs       string `yaml:"globs,omitempty"`
+}
+
+// renderRule renders body below Windsurf front-matter built from meta
+func renderRule(meta map[string]interface{}, body []byte) ([]byte, error) {
+	trigger, err := Trigger(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter := ruleFrontMatter{Trigger: trigger}
+	frontMatter.Description, _ = meta["description"].(string)
+	if trigger == TriggerGlob {
+		frontMatter.Globs = strings.Join(adapter.StringList(meta["globs"]), ",")
+		if frontMatter.Globs == "" {
+			return nil, fmt.Errorf("trigger %s needs globs", TriggerGlob)
+		}
+	}
+
+	yamlBytes, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(yamlBytes)
+	buf.WriteString("---\n\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
@@ -32,6 +32,9 @@ The source should be a Git repository URL, optionally with a ref (branch, tag, o
   - github.com/org/prompts#main
 
 By default, the command will check if the source is trusted and then run installation.
+A source is trusted when it matches a repo (or a "namespace/*" wildcard) declared in
+the sources: block of ~/.prompt-sync/config.yaml or a trusted_sources: block of it,
+Promptsfile or Promptsfile.local.
 Use --no-install to skip the installation step.`,
 		Args: cobra.ExactArgs(1),
 		RunE: runAdd,
@@ -78,15 +81,20 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading Promptsfile: %w", err)
 	}
 
-	// Extract base URL (without ref) for trusted source checking
-	baseURL := strings.Split(source, "#")[0]
+	// Extract the repository (without ref) for trusted source checking
+	baseURL := config.ParseSource(source).Repo
 
 	// Check if source is trusted (unless --allow-unknown is set)
 	if !addAllowUnknown {
-		trustedSources := security.NewTrustedSources()
-		if !trustedSources.IsTrusted(baseURL) {
-			return fmt.Errorf("untrusted source: %s. Use --allow-unknown to bypass this check", baseURL)
+		trustedSources, err := security.LoadTrustedSources(promptsDir)
+		if err != nil {
+			return err
+		}
+		trustedBy, ok := trustedSources.TrustedBy(baseURL)
+		if !ok {
+			return security.UntrustedError(baseURL)
 		}
+		fmt.Printf("✓ Trusted by %s in %s\n", trustedBy.Repo, trustedBy.File)
 	}
 
 	// Check for duplicates
@@ -95,7 +103,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Add the source
-	cfg.Sources = append(cfg.Sources, source)
+	cfg.Sources = append(cfg.Sources, config.ParseSource(source))
 
 	// Write updated configuration
 	if err := writeConfig(promptsfilePath, cfg); err != nil {
@@ -155,13 +163,12 @@ func validateSourceURL(source string) error {
 func checkDuplicate(cfg *config.ExtendedConfig, source string) error {
 	// Check in regular sources
 	for _, existing := range cfg.Sources {
-		if existing == source {
+		if existing.String() == source {
 			return fmt.Errorf("source '%s' already exists in Promptsfile", source)
 		}
 		// Also check if it's the same URL with different ref
-		existingBase := strings.Split(existing, "#")[0]
-		sourceBase := strings.Split(source, "#")[0]
-		if existingBase == sourceBase {
+		sourceBase := config.ParseSource(source).URL()
+		if existing.URL() == sourceBase {
 			return fmt.Errorf("source '%s' already exists (as '%s')", sourceBase, existing)
 		}
 	}
@@ -14,21 +14,42 @@ var (
 	installCacheDir     string
 	installAllowUnknown bool
 	installYes          bool
+	installFrozen       bool
+	installJobs         int
+	installThreshold    string
+	installApprove      bool
 )
 
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install prompt packs from Promptsfile",
 	Long: `Install fetches all prompt packs specified in your Promptsfile,
-renders them using the configured adapters, and creates a lock file.`,
+renders them using the configured adapters, and creates a lock file.
+
+When a lock file exists, sources are checked out at the exact commits it
+records. Only new sources and sources whose ref changed are re-resolved.
+Use --frozen-lockfile to fail instead when the Promptsfile and lock disagree.
+
+Sources are fetched and rendered in parallel, limited by --jobs (default: the
+number of CPUs).
+
+Rendered files are staged and checked before the workspace is changed. If
+writing the output or the lock file fails, the previous files are restored, and
+an install that was interrupted is rolled back by the next one.
+
+Prompts may declare "security: low|medium|high". Prompts above the threshold
+(--security-threshold or "security: threshold:" in the Promptsfile) and prompts
+whose level increased since the lock file was written produce warnings, or
+errors with --strict. Use --approve-security to accept increased levels.`,
 	RunE: runInstall,
 }
 
 var ciInstallCmd = &cobra.Command{
 	Use:   "ci-install",
-	Short: "Install prompt packs in CI mode (alias for install --yes --strict)",
-	Long: `CI-friendly installation that runs non-interactively with strict error checking.
-Equivalent to: prompt-sync install --yes --strict`,
+	Short: "Install prompt packs in CI mode (alias for install --yes --strict --frozen-lockfile)",
+	Long: `CI-friendly installation that runs non-interactively with strict error checking
+and never re-resolves sources that are missing from or changed in the lock file.
+Equivalent to: prompt-sync install --yes --strict --frozen-lockfile`,
 	RunE: runCIInstall,
 }
 
@@ -41,6 +62,10 @@ func init() {
 	installCmd.Flags().StringVar(&installCacheDir, "cache-dir", "", "Override cache directory")
 	installCmd.Flags().BoolVar(&installAllowUnknown, "allow-unknown", false, "Allow untrusted sources")
 	installCmd.Flags().BoolVarP(&installYes, "yes", "y", false, "Assume yes to all prompts")
+	installCmd.Flags().BoolVar(&installFrozen, "frozen-lockfile", false, "Fail if Promptsfile.lock is missing or out of date")
+	installCmd.Flags().IntVarP(&installJobs, "jobs", "j", 0, "Number of sources to fetch and render in parallel (default: number of CPUs)")
+	installCmd.Flags().StringVar(&installThreshold, "security-threshold", "", "Highest prompt security level to install without a warning (low, medium or high)")
+	installCmd.Flags().BoolVar(&installApprove, "approve-security", false, "Approve prompts whose security level increased")
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
@@ -63,6 +88,11 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		Offline:      installOffline,
 		CacheDir:     installCacheDir,
 		AllowUnknown: installAllowUnknown,
+
+		FrozenLockfile:    installFrozen,
+		Jobs:              installJobs,
+		SecurityThreshold: installThreshold,
+		ApproveSecurity:   installApprove,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create installer: %w", err)
@@ -81,6 +111,7 @@ func runCIInstall(cmd *cobra.Command, args []string) error {
 	// Set CI mode flags
 	installStrict = true
 	installYes = true
+	installFrozen = true
 
 	// Run regular install
 	return runInstall(cmd, args)
@@ -10,13 +10,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/git"
 	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/security"
 )
 
 var (
 	showFiles    bool
 	showOutdated bool
 	outputJSON   bool
+	listOffline  bool
+	listCacheDir string
 )
 
 // ListCmd represents the list command
@@ -34,6 +38,8 @@ func init() {
 	ListCmd.Flags().BoolVar(&showFiles, "files", false, "Show rendered file paths")
 	ListCmd.Flags().BoolVar(&showOutdated, "outdated", false, "Show only outdated packages")
 	ListCmd.Flags().BoolVar(&outputJSON, "json", false, "Output in JSON format")
+	ListCmd.Flags().BoolVar(&listOffline, "offline", false, "Check --outdated against cached repositories only")
+	ListCmd.Flags().StringVar(&listCacheDir, "cache-dir", "", "Override cache directory")
 
 	RootCmd.AddCommand(ListCmd)
 }
@@ -66,12 +72,25 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading lock file: %w", err)
 	}
 
+	// Rulesets pick packs of named sources
+	trustedSources, err := security.LoadTrustedSources(promptsDir)
+	if err != nil {
+		return err
+	}
+	selected, err := cfg.Selected(trustedSources.Named)
+	if err != nil {
+		return err
+	}
+	for _, overlay := range cfg.Overlays {
+		selected = append(selected, config.ParseSource(overlay.Source))
+	}
+
 	// Build source information
-	sources := buildPromptInfo(cfg, lockData, workDir)
+	sources := buildPromptInfo(selected, lockData, workDir)
 
-	// Filter if --outdated is specified (not implemented yet)
+	// Filter if --outdated is specified
 	if showOutdated {
-		sources = filterOutdated(sources)
+		sources = filterOutdated(cmd, git.NewFetcher(gitOptions(listOffline, listCacheDir)...), sources)
 	}
 
 	// Output results
@@ -82,18 +101,40 @@ func runList(cmd *cobra.Command, args []string) error {
 }
 
 type sourceInfo struct {
-	URL           string   `json:"url"`
-	Commit        string   `json:"commit,omitempty"`
-	Ref           string   `json:"ref,omitempty"`
-	RenderedFiles []string `json:"rendered_files,omitempty"`
-	Installed     bool     `json:"installed"`
+	URL           string            `json:"url"`
+	Commit        string            `json:"commit,omitempty"`
+	Ref           string            `json:"ref,omitempty"`
+	Version       string            `json:"version,omitempty"`
+	Scope         string            `json:"scope,omitempty"`
+	RenderedFiles []string          `json:"rendered_files,omitempty"`
+	FileTargets   map[string]string `json:"file_targets,omitempty"` // Rendered file -> targets entry that selected it
+	ShadowedFiles []shadowedInfo    `json:"shadowed_files,omitempty"`
+	SkippedFiles  []skippedInfo     `json:"skipped_files,omitempty"`
+	Installed     bool              `json:"installed"`
+	LatestCommit  string            `json:"latest_commit,omitempty"`
+	CommitsBehind int               `json:"commits_behind,omitempty"`
+	NewerTags     []string          `json:"newer_tags,omitempty"`
+}
+
+// shadowedInfo is a file of a source that a higher scope replaced
+type shadowedInfo struct {
+	Path            string `json:"path"`
+	ShadowedBy      string `json:"shadowed_by"`
+	ShadowedByScope string `json:"shadowed_by_scope"`
+}
+
+// skippedInfo is a prompt an adapter left out because of its targets
+type skippedInfo struct {
+	Source  string `json:"source"`
+	Adapter string `json:"adapter"`
+	Reason  string `json:"reason"`
 }
 
 type listJSONOutput struct {
 	Sources []sourceInfo `json:"sources"`
 }
 
-func buildPromptInfo(cfg *config.ExtendedConfig, lockData *lock.Lock, workDir string) []sourceInfo {
+func buildPromptInfo(configured []config.SourceCfg, lockData *lock.Lock, workDir string) []sourceInfo {
 	var sources []sourceInfo
 
 	// Create a map of lock entries for quick lookup
@@ -105,19 +146,8 @@ func buildPromptInfo(cfg *config.ExtendedConfig, lockData *lock.Lock, workDir st
 	}
 
 	// Process sources
-	allSources := append([]string{}, cfg.Sources...)
-	for _, overlay := range cfg.Overlays {
-		allSources = append(allSources, overlay.Source)
-	}
-
-	for _, source := range allSources {
-		// Extract URL and ref
-		parts := strings.Split(source, "#")
-		url := parts[0]
-		ref := ""
-		if len(parts) > 1 {
-			ref = parts[1]
-		}
+	for _, source := range configured {
+		url, ref := source.URL(), source.Ref
 
 		info := sourceInfo{
 			URL:       url,
@@ -129,11 +159,34 @@ func buildPromptInfo(cfg *config.ExtendedConfig, lockData *lock.Lock, workDir st
 		if lockEntry, exists := lockMap[url]; exists {
 			info.Installed = true
 			info.Commit = lockEntry.Commit
+			info.Version = lockEntry.Version
+			info.Scope = lockEntry.Scope
+
+			for _, file := range lockEntry.Shadowed {
+				info.ShadowedFiles = append(info.ShadowedFiles, shadowedInfo{
+					Path:            file.Path,
+					ShadowedBy:      file.By,
+					ShadowedByScope: file.ByScope,
+				})
+			}
 
 			// If showing files, get rendered file paths
 			if showFiles {
 				for _, file := range lockEntry.Files {
 					info.RenderedFiles = append(info.RenderedFiles, file.Path)
+					if file.Target != "" {
+						if info.FileTargets == nil {
+							info.FileTargets = make(map[string]string)
+						}
+						info.FileTargets[file.Path] = file.Target
+					}
+				}
+				for _, file := range lockEntry.Skipped {
+					info.SkippedFiles = append(info.SkippedFiles, skippedInfo{
+						Source:  file.SourcePath,
+						Adapter: file.Adapter,
+						Reason:  file.Reason,
+					})
 				}
 			}
 		}
@@ -144,23 +197,47 @@ func buildPromptInfo(cfg *config.ExtendedConfig, lockData *lock.Lock, workDir st
 	return sources
 }
 
-func filterOutdated(sources []sourceInfo) []sourceInfo {
-	// TODO: Implement checking for updates
-	// For now, return empty list
-	return []sourceInfo{}
+// filterOutdated queries the remote of every installed source and keeps only
+// the ones whose ref moved or that have newer version tags available.
+func filterOutdated(cmd *cobra.Command, fetcher git.Fetcher, sources []sourceInfo) []sourceInfo {
+	outdated := []sourceInfo{}
+	for _, s := range sources {
+		if !s.Installed {
+			continue
+		}
+
+		status, err := checkSourceStatus(fetcher, s.URL, s.Ref, s.Commit)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not check %s for updates: %v\n", s.URL, err)
+			continue
+		}
+		if !status.IsOutdated() {
+			continue
+		}
+
+		s.LatestCommit = status.LatestCommit
+		s.CommitsBehind = status.CommitsBehind
+		s.NewerTags = status.NewerTags
+		outdated = append(outdated, s)
+	}
+	return outdated
 }
 
 func outputTableFormat(cmd *cobra.Command, sources []sourceInfo) error {
 	out := cmd.OutOrStdout()
 
+	if showOutdated && len(sources) == 0 {
+		fmt.Fprintln(out, "All sources are up to date")
+		return nil
+	}
+
 	if len(sources) == 0 {
 		fmt.Fprintln(out, "No sources configured")
 		return nil
 	}
 
-	if showOutdated && len(sources) == 0 {
-		fmt.Fprintln(out, "All sources are up to date")
-		return nil
+	if showOutdated {
+		return outputOutdatedTable(cmd, sources)
 	}
 
 	// Print header
@@ -178,6 +255,16 @@ func outputTableFormat(cmd *cobra.Command, sources []sourceInfo) error {
 		commit := ""
 		if s.Installed {
 			status = "installed"
+			var notes []string
+			if s.Scope != "" && s.Scope != "project" {
+				notes = append(notes, s.Scope)
+			}
+			if len(s.ShadowedFiles) > 0 {
+				notes = append(notes, fmt.Sprintf("%d shadowed", len(s.ShadowedFiles)))
+			}
+			if len(notes) > 0 {
+				status += " (" + strings.Join(notes, ", ") + ")"
+			}
 			if len(s.Commit) > 7 {
 				commit = s.Commit[:7]
 			} else {
@@ -189,11 +276,24 @@ func outputTableFormat(cmd *cobra.Command, sources []sourceInfo) error {
 		if ref == "" {
 			ref = "-"
 		}
+		if s.Version != "" {
+			ref += " (" + s.Version + ")"
+		}
 
-		if showFiles && len(s.RenderedFiles) > 0 {
+		if showFiles && (len(s.RenderedFiles) > 0 || len(s.ShadowedFiles) > 0 || len(s.SkippedFiles) > 0) {
 			fmt.Fprintf(out, "%-50s %-10s %-12s\n", s.URL, ref, commit)
 			for _, file := range s.RenderedFiles {
-				fmt.Fprintf(out, "%-73s %s\n", "", file)
+				if target, ok := s.FileTargets[file]; ok {
+					fmt.Fprintf(out, "%-73s %s (targets: %s)\n", "", file, target)
+				} else {
+					fmt.Fprintf(out, "%-73s %s\n", "", file)
+				}
+			}
+			for _, file := range s.ShadowedFiles {
+				fmt.Fprintf(out, "%-73s %s (shadowed by %s, %s)\n", "", file.Path, file.ShadowedBy, file.ShadowedByScope)
+			}
+			for _, file := range s.SkippedFiles {
+				fmt.Fprintf(out, "%-73s %s not rendered for %s (%s)\n", "", file.Source, file.Adapter, file.Reason)
 			}
 		} else {
 			fmt.Fprintf(out, "%-50s %-10s %-12s %-10s\n", s.URL, ref, commit, status)
@@ -203,6 +303,35 @@ func outputTableFormat(cmd *cobra.Command, sources []sourceInfo) error {
 	return nil
 }
 
+func outputOutdatedTable(cmd *cobra.Command, sources []sourceInfo) error {
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintf(out, "%-50s %-10s %-12s %-12s %s\n", "SOURCE", "REF", "COMMIT", "LATEST", "STATUS")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+
+	for _, s := range sources {
+		ref := s.Ref
+		if ref == "" {
+			ref = "-"
+		}
+
+		var status []string
+		switch {
+		case s.CommitsBehind > 0:
+			status = append(status, fmt.Sprintf("%d commit(s) behind", s.CommitsBehind))
+		case s.LatestCommit != s.Commit:
+			status = append(status, "new commits")
+		}
+		if len(s.NewerTags) > 0 {
+			status = append(status, "newer tags: "+strings.Join(s.NewerTags, ", "))
+		}
+
+		fmt.Fprintf(out, "%-50s %-10s %-12s %-12s %s\n", s.URL, ref, shortCommit(s.Commit), shortCommit(s.LatestCommit), strings.Join(status, "; "))
+	}
+
+	return nil
+}
+
 func outputJSONFormat(cmd *cobra.Command, sources []sourceInfo) error {
 	output := listJSONOutput{Sources: sources}
 	encoder := json.NewEncoder(cmd.OutOrStdout())
@@ -11,6 +11,7 @@ import (
 
 	"github.com/kovyrin/prompt-sync/internal/config"
 	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/security"
 )
 
 // NewRemoveCommand creates a new remove command
@@ -66,11 +67,21 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading Promptsfile: %w", err)
 	}
 
-	// Find and remove the source
+	// Find and remove the source, or the ruleset that selected it
 	removed, updatedSources, err := removeSource(cfg.Sources, source)
 	if err != nil {
 		return err
 	}
+	lockedURL := source
+	var rulesetSource config.SourceCfg
+	if !removed {
+		removed, rulesetSource, err = removeRuleset(cfg, promptsDir, source)
+		if err != nil {
+			return err
+		}
+		lockedURL = rulesetSource.URL()
+		updatedSources = cfg.Sources
+	}
 	if !removed {
 		// Check if it's in overlays
 		for _, overlay := range cfg.Overlays {
@@ -89,13 +100,13 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	lockData, err := lockWriter.Read()
 	if err == nil && lockData != nil {
 		// Clean up rendered files
-		if err := cleanupRenderedFiles(workDir, source, lockData); err != nil {
+		if err := cleanupRenderedFiles(workDir, lockedURL, lockData); err != nil {
 			// Log warning but don't fail
 			fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to clean up some files: %v\n", err)
 		}
 
 		// Update lock file
-		if err := updateLockFile(lockWriter, lockData, source); err != nil {
+		if err := updateLockFile(lockWriter, lockData, lockedURL); err != nil {
 			fmt.Fprintf(cmd.OutOrStderr(), "Warning: failed to update lock file: %v\n", err)
 		}
 	}
@@ -107,6 +118,10 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("✓ Removed source: %s\n", source)
 
+	if rulesetSource.Ruleset != "" && declaresSource(cfg, rulesetSource.Name) && !selectsFrom(cfg, rulesetSource.Name) {
+		fmt.Printf("Note: %s is no longer selected by rulesets and will be installed whole\n", rulesetSource.Name)
+	}
+
 	// Check if gitignore needs updating
 	if len(cfg.Sources) == 0 && len(cfg.Overlays) == 0 {
 		fmt.Println("Note: All sources removed. You may want to clean up .gitignore")
@@ -115,16 +130,15 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func removeSource(sources []string, target string) (bool, []string, error) {
+func removeSource(sources []config.SourceCfg, target string) (bool, []config.SourceCfg, error) {
 	// Extract base URL from target (remove version spec)
-	targetBase := strings.Split(target, "#")[0]
+	targetBase := config.ParseSource(target).URL()
 
-	var updatedSources []string
+	var updatedSources []config.SourceCfg
 	found := false
 
 	for _, source := range sources {
-		sourceBase := strings.Split(source, "#")[0]
-		if sourceBase == targetBase {
+		if source.URL() == targetBase {
 			found = true
 			// Skip this source (remove it)
 		} else {
@@ -135,6 +149,54 @@ func removeSource(sources []string, target string) (bool, []string, error) {
 	return found, updatedSources, nil
 }
 
+// removeRuleset removes the rulesets: entry matching target, with or without
+// its ref, and returns the pack it selected
+func removeRuleset(cfg *config.ExtendedConfig, promptsDir, target string) (bool, config.SourceCfg, error) {
+	trustedSources, err := security.LoadTrustedSources(promptsDir)
+	if err != nil {
+		return false, config.SourceCfg{}, err
+	}
+	selected, err := cfg.Selected(trustedSources.Named)
+	if err != nil {
+		return false, config.SourceCfg{}, err
+	}
+
+	for _, source := range selected {
+		if !matchesRuleset(source.Ruleset, target) {
+			continue
+		}
+		var rulesets []string
+		for _, ruleset := range cfg.Rulesets {
+			if ruleset != source.Ruleset {
+				rulesets = append(rulesets, ruleset)
+			}
+		}
+		cfg.Rulesets = rulesets
+		return true, source, nil
+	}
+	return false, config.SourceCfg{}, nil
+}
+
+// declaresSource reports whether the Promptsfile declares the named source
+func declaresSource(cfg *config.ExtendedConfig, name string) bool {
+	for _, source := range cfg.Sources {
+		if source.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectsFrom reports whether any ruleset selects a pack of the named source
+func selectsFrom(cfg *config.ExtendedConfig, name string) bool {
+	for _, ruleset := range cfg.Rulesets {
+		if rulesetSource, _, _, err := config.ParseRuleset(ruleset); err == nil && rulesetSource == name {
+			return true
+		}
+	}
+	return false
+}
+
 func matchesSource(source, target string) bool {
 	sourceBase := strings.Split(source, "#")[0]
 	targetBase := strings.Split(target, "#")[0]
@@ -4,12 +4,15 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/kovyrin/prompt-sync/internal/version"
 )
 
 // RootCmd is the main entry point for all prompt-sync subcommands.
 var RootCmd = &cobra.Command{
-	Use:   "prompt-sync",
-	Short: "Prompt-Sync CLI – AI prompt package manager",
+	Use:     "prompt-sync",
+	Short:   "Prompt-Sync CLI – AI prompt package manager",
+	Version: version.Version,
 }
 
 // Execute executes the root command and exits on failure.
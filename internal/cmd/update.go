@@ -10,6 +10,9 @@ import (
 
 	"github.com/kovyrin/prompt-sync/internal/config"
 	"github.com/kovyrin/prompt-sync/internal/git"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/security"
+	"github.com/kovyrin/prompt-sync/internal/semver"
 	"github.com/kovyrin/prompt-sync/internal/workflow"
 )
 
@@ -20,6 +23,7 @@ var (
 	updateOffline      bool
 	updateCacheDir     string
 	updateAllowUnknown bool
+	updateMajor        bool
 )
 
 // NewUpdateCommand creates a new update command
@@ -29,8 +33,14 @@ func NewUpdateCommand() *cobra.Command {
 		Short: "Update prompt sources to their latest versions",
 		Long: `Update prompt sources to their latest versions while respecting version constraints.
 
-Without arguments, updates all unpinned sources to their latest versions.
-With source arguments, updates only the specified sources.
+Without arguments, updates all unpinned sources to their latest versions,
+including the packs installed because a pack.yaml requires them. With source
+arguments, updates only the specified sources; a required pack may be named
+by its URL or by the name packs require it by.
+
+Sources with a version range ref (e.g. #^1.2, #~2.0.3 or "#>=1.0 <2.0") move to
+the highest matching tag. Use --major to rewrite the range in Promptsfile when
+a newer major version is available.
 
 Examples:
   # Update all unpinned sources
@@ -42,6 +52,9 @@ Examples:
   # Check what would be updated without making changes
   prompt-sync update --dry-run
 
+  # Allow version ranges to cross major versions
+  prompt-sync update --major
+
   # Force update even pinned sources
   prompt-sync update --force github.com/org/prompts#v1.0.0`,
 		RunE: runUpdate,
@@ -53,6 +66,7 @@ Examples:
 	cmd.Flags().BoolVar(&updateOffline, "offline", false, "Use only cached repositories")
 	cmd.Flags().StringVar(&updateCacheDir, "cache-dir", "", "Override cache directory")
 	cmd.Flags().BoolVar(&updateAllowUnknown, "allow-unknown", false, "Allow untrusted sources")
+	cmd.Flags().BoolVar(&updateMajor, "major", false, "Allow version ranges to move to a new major version")
 
 	return cmd
 }
@@ -95,8 +109,24 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading Promptsfile: %w", err)
 	}
 
-	// Determine which sources to update
-	sourcesToUpdate, err := determineSourcesToUpdate(cfg, args)
+	// Rulesets pick packs of named sources
+	trustedSources, err := security.LoadTrustedSources(promptsDir)
+	if err != nil {
+		return err
+	}
+	selected, err := cfg.Selected(trustedSources.Named)
+	if err != nil {
+		return err
+	}
+
+	lockData, err := lock.New(promptsDir).Read()
+	if err != nil {
+		return fmt.Errorf("loading lock file: %w", err)
+	}
+
+	// Determine which sources to update, including the packs installed as
+	// dependencies of other packs
+	sourcesToUpdate, err := determineSourcesToUpdate(selected, lockedDependencies(lockData, selected), args)
 	if err != nil {
 		return err
 	}
@@ -110,13 +140,18 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Checking for updates to %d source(s)...\n", len(sourcesToUpdate))
 
 	// Check for updates
-	updates, err := checkForUpdates(workDir, sourcesToUpdate)
+	fetcher := git.NewFetcher(gitOptions(updateOffline, updateCacheDir)...)
+	updates, failed, err := checkForUpdates(cmd, fetcher, lockData, sourcesToUpdate)
 	if err != nil {
 		return fmt.Errorf("checking for updates: %w", err)
 	}
 
 	if len(updates) == 0 {
-		fmt.Println("✓ All sources are up to date")
+		if failed > 0 {
+			fmt.Printf("No updates found, %d source(s) could not be checked\n", failed)
+		} else {
+			fmt.Println("✓ All sources are up to date")
+		}
 		return nil
 	}
 
@@ -129,21 +164,53 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Only sources whose ref moved can be updated in place
+	var updatedURLs []string
+	for _, update := range updates {
+		if update.HasNewCommits() {
+			updatedURLs = append(updatedURLs, update.URL)
+		}
+	}
+	if len(updatedURLs) == 0 {
+		fmt.Println("\nNo new commits on the configured refs. Change the ref in Promptsfile to move to a newer tag, or use --major for version ranges.")
+		return nil
+	}
+
 	// Apply updates
 	fmt.Println("\nApplying updates...")
 
-	// Update Promptsfile for unpinned sources that need new refs
-	if err := updatePromptsfile(cfg, updates); err != nil {
+	// Major updates rewrite the version range in Promptsfile. The install
+	// reads it from disk, so the original is restored when the install fails
+	// to keep the Promptsfile in step with the lock file.
+	original, err := os.ReadFile(promptsPath)
+	if err != nil {
+		return fmt.Errorf("reading Promptsfile: %w", err)
+	}
+	if err := updatePromptsfile(promptsPath, cfg, selected, updates); err != nil {
 		return fmt.Errorf("updating Promptsfile: %w", err)
 	}
+	if err := applyUpdates(workDir, updatedURLs); err != nil {
+		if restoreErr := os.WriteFile(promptsPath, original, 0644); restoreErr != nil {
+			return fmt.Errorf("%w (restoring Promptsfile: %v)", err, restoreErr)
+		}
+		return err
+	}
+
+	fmt.Printf("\n✓ Updated %d source(s)\n", len(updatedURLs))
+	return nil
+}
 
-	// Run install to apply all updates
+// applyUpdates runs an install that re-resolves the given sources
+func applyUpdates(workDir string, updatedURLs []string) error {
 	installer, err := workflow.New(workflow.InstallOptions{
 		WorkspaceDir: workDir,
 		StrictMode:   updateStrict,
 		Offline:      updateOffline,
 		CacheDir:     updateCacheDir,
 		AllowUnknown: updateAllowUnknown,
+
+		// Re-resolve the selected sources instead of reusing locked commits
+		UpdateSources: updatedURLs,
 	})
 	if err != nil {
 		return fmt.Errorf("creating installer: %w", err)
@@ -152,30 +219,93 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err := installer.Execute(); err != nil {
 		return fmt.Errorf("applying updates: %w", err)
 	}
-
-	fmt.Printf("\n✓ Updated %d source(s)\n", len(updates))
 	return nil
 }
 
+// sourceUpdate describes the difference between a locked source and its remote
 type sourceUpdate struct {
-	URL         string
-	CurrentRef  string
-	CurrentHash string
-	NewRef      string
-	NewHash     string
-	IsPinned    bool
+	URL           string
+	Ref           string
+	CurrentCommit string   // Commit recorded in the lock file
+	LatestCommit  string   // Commit the ref points to on the remote
+	CommitsBehind int      // Number of new commits, -1 when unknown
+	NewerTags     []string // Version tags newer than the installed version
+	LatestVersion string   // Tag selected for a version range
+	NewRef        string   // Replacement version range for a major update
+	IsPinned      bool
+
+	tags map[string]string // Remote tags, used to plan major updates
+}
+
+// HasNewCommits reports whether the ref moved since the lock file was written
+func (u *sourceUpdate) HasNewCommits() bool {
+	return u.LatestCommit != "" && u.LatestCommit != u.CurrentCommit
 }
 
-func determineSourcesToUpdate(cfg *config.ExtendedConfig, args []string) ([]string, error) {
+// IsOutdated reports whether there is anything newer than the installed commit
+func (u *sourceUpdate) IsOutdated() bool {
+	return u.HasNewCommits() || len(u.NewerTags) > 0
+}
+
+// lockedDependency is a pack the lock file installs only because other packs
+// require it
+type lockedDependency struct {
+	spec string // url#ref of its locked source
+	pack string // Name the requiring packs give it, such as shared/base
+}
+
+// lockedDependencies returns the sources of the lock file that no Promptsfile
+// entry selects but that the pack.yaml of another locked pack requires
+func lockedDependencies(lockData *lock.Lock, selected []config.SourceCfg) []lockedDependency {
+	if lockData == nil {
+		return nil
+	}
+
+	configured := make(map[string]bool)
+	for _, source := range selected {
+		configured[source.URL()] = true
+	}
+	names := make(map[string]string) // URL -> pack name
+	for _, source := range lockData.Sources {
+		for _, dep := range source.Dependencies {
+			if _, ok := names[dep.URL]; !ok {
+				names[dep.URL] = dep.Pack
+			}
+		}
+	}
+
+	var deps []lockedDependency
+	for _, source := range lockData.Sources {
+		url := strings.Split(source.URL, "#")[0]
+		name, required := names[url]
+		if !required || configured[url] {
+			continue
+		}
+		spec := url
+		if source.Ref != "" {
+			spec += "#" + source.Ref
+		}
+		deps = append(deps, lockedDependency{spec: spec, pack: name})
+	}
+	return deps
+}
+
+func determineSourcesToUpdate(selected []config.SourceCfg, deps []lockedDependency, args []string) ([]string, error) {
 	if len(args) == 0 {
 		// Update all sources
-		sources := make([]string, 0, len(cfg.Sources))
-		for _, source := range cfg.Sources {
+		sources := make([]string, 0, len(selected)+len(deps))
+		for _, source := range selected {
 			// Skip pinned sources unless --force is set
-			if !updateForce && isPinnedSource(source) {
+			if !updateForce && isPinnedSource(source.String()) {
+				continue
+			}
+			sources = append(sources, source.String())
+		}
+		for _, dep := range deps {
+			if !updateForce && isPinnedSource(dep.spec) {
 				continue
 			}
-			sources = append(sources, source)
+			sources = append(sources, dep.spec)
 		}
 		return sources, nil
 	}
@@ -185,17 +315,30 @@ func determineSourcesToUpdate(cfg *config.ExtendedConfig, args []string) ([]stri
 	for _, arg := range args {
 		// Find matching source
 		found := false
-		for _, source := range cfg.Sources {
-			if matchesSourceURL(source, arg) {
+		for _, source := range selected {
+			if matchesSourceURL(source.String(), arg) || matchesRuleset(source.Ruleset, arg) {
 				// Check if pinned and force not set
-				if !updateForce && isPinnedSource(source) {
+				if !updateForce && isPinnedSource(source.String()) {
 					return nil, fmt.Errorf("source '%s' is pinned to a specific version. Use --force to update", source)
 				}
-				sources = append(sources, source)
+				sources = append(sources, source.String())
 				found = true
 				break
 			}
 		}
+		for _, dep := range deps {
+			if found {
+				break
+			}
+			// Dependencies are named by URL or by the name packs require them by
+			if matchesSourceURL(dep.spec, arg) || dep.pack == strings.Split(arg, "@")[0] {
+				if !updateForce && isPinnedSource(dep.spec) {
+					return nil, fmt.Errorf("source '%s' is pinned to a specific version. Use --force to update", dep.spec)
+				}
+				sources = append(sources, dep.spec)
+				found = true
+			}
+		}
 		if !found {
 			return nil, fmt.Errorf("source '%s' not found in Promptsfile", arg)
 		}
@@ -210,18 +353,10 @@ func isPinnedSource(source string) bool {
 		return false // No ref, not pinned
 	}
 
+	// Exact version tags and commit hashes never move, while branches and
+	// version ranges follow new commits and tags
 	ref := parts[1]
-	// Consider it pinned if it's a specific tag/version or commit hash
-	// Branch names like "main", "master", "develop" are not considered pinned
-	unpinnedBranches := []string{"main", "master", "develop", "dev"}
-	for _, branch := range unpinnedBranches {
-		if ref == branch {
-			return false
-		}
-	}
-
-	// If it looks like a version tag or commit hash, it's pinned
-	return true
+	return semver.IsVersion(ref) || git.IsCommitHash(ref)
 }
 
 func matchesSourceURL(source, target string) bool {
@@ -230,63 +365,236 @@ func matchesSourceURL(source, target string) bool {
 	return sourceBase == targetBase
 }
 
-func checkForUpdates(workDir string, sources []string) ([]sourceUpdate, error) {
-	// This is a simplified version - in a real implementation,
-	// we would use the git fetcher to check for actual updates
-	updates := []sourceUpdate{}
+// matchesRuleset reports whether target names a rulesets: entry, with or
+// without its ref
+func matchesRuleset(ruleset, target string) bool {
+	if ruleset == "" {
+		return false
+	}
+	return strings.Split(ruleset, "@")[0] == strings.Split(target, "@")[0]
+}
 
-	// For now, just mark all sources as having updates available
-	// In a real implementation, this would:
-	// 1. Use git fetcher to check remote for new commits
-	// 2. Compare with current lock file
-	// 3. Respect version constraints
+// checkForUpdates queries the remote of every source and returns the ones that
+// are outdated compared to the lock file, along with the number of sources that
+// could not be checked. Query failures are warnings unless --strict is set.
+func checkForUpdates(cmd *cobra.Command, fetcher git.Fetcher, lockData *lock.Lock, sources []string) ([]sourceUpdate, int, error) {
+	locked := make(map[string]lock.Source)
+	if lockData != nil {
+		for _, source := range lockData.Sources {
+			locked[strings.Split(source.URL, "#")[0]] = source
+		}
+	}
 
+	var updates []sourceUpdate
+	failed := 0
 	for _, source := range sources {
-		// Skip this basic implementation for testing
-		update := sourceUpdate{
-			URL:      source,
-			IsPinned: isPinnedSource(source),
+		parts := strings.Split(source, "#")
+		url := parts[0]
+		ref := ""
+		if len(parts) > 1 {
+			ref = parts[1]
+		}
+
+		status, err := checkSourceStatus(fetcher, url, ref, locked[url].Commit)
+		if err != nil {
+			if updateStrict {
+				return nil, failed, fmt.Errorf("%s: %w", url, err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not check %s for updates: %v\n", url, err)
+			failed++
+			continue
+		}
+
+		status.IsPinned = isPinnedSource(source)
+		if updateMajor && semver.IsConstraint(ref) {
+			planMajorUpdate(status, ref)
+		}
+		if status.IsOutdated() {
+			updates = append(updates, *status)
+		}
+	}
+
+	return updates, failed, nil
+}
+
+// checkSourceStatus compares the commit a source is installed at with the
+// current state of its ref on the remote.
+func checkSourceStatus(fetcher git.Fetcher, url, ref, currentCommit string) (*sourceUpdate, error) {
+	// Sources in a directory of a repository follow the whole repository
+	repo, _ := config.SplitSubdirectory(url)
+	remote, err := fetcher.ResolveRemote(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &sourceUpdate{
+		URL:           url,
+		Ref:           ref,
+		CurrentCommit: currentCommit,
+		LatestCommit:  remote.Commit,
+		CommitsBehind: -1,
+		LatestVersion: remote.Version,
+		tags:          remote.Tags,
+	}
+
+	if !status.HasNewCommits() {
+		status.CommitsBehind = 0
+	} else if currentCommit != "" {
+		if behind, err := fetcher.CommitsBehind(repo, currentCommit, remote.Commit); err == nil {
+			status.CommitsBehind = behind
+		}
+	}
+
+	status.NewerTags = newerTags(remote, ref, currentCommit)
+
+	return status, nil
+}
+
+// planMajorUpdate points a version range source at the newest tag when it is
+// outside the configured range. The range keeps its operator, so "^1.2" becomes
+// "^2.0.0" and "~1.4.1" becomes "~2.1.0".
+func planMajorUpdate(status *sourceUpdate, ref string) {
+	if len(status.NewerTags) == 0 {
+		return
+	}
+
+	constraint, err := semver.ParseConstraint(ref)
+	if err != nil {
+		return
+	}
+
+	newest, err := semver.Parse(status.NewerTags[len(status.NewerTags)-1])
+	if err != nil || constraint.Check(newest) {
+		return
+	}
+
+	operator := "^"
+	if strings.HasPrefix(ref, "~") {
+		operator = "~"
+	}
+	status.NewRef = operator + newest.String()
+	status.LatestVersion = newest.Original
+	status.LatestCommit = status.tags[newest.Original]
+}
+
+// newerTags returns the version tags that are newer than the installed version.
+// The installed version is the ref itself when it is a version tag, otherwise
+// the highest version tag pointing at the installed commit.
+func newerTags(remote *git.RemoteRef, ref, currentCommit string) []string {
+	var current *semver.Version
+	if v, err := semver.Parse(ref); err == nil && remote.Tags[ref] != "" {
+		current = &v
+	} else {
+		versions := semver.ParseAll(remote.TagNames())
+		for i := range versions {
+			if remote.Tags[versions[i].Original] == currentCommit {
+				current = &versions[i]
+			}
 		}
-		updates = append(updates, update)
 	}
 
-	return updates, nil
+	if current == nil {
+		return nil
+	}
+
+	var tags []string
+	for _, v := range semver.ParseAll(remote.TagNames()) {
+		if v.Compare(*current) > 0 {
+			tags = append(tags, v.Original)
+		}
+	}
+	return tags
 }
 
 func displayUpdates(cmd *cobra.Command, updates []sourceUpdate) {
-	fmt.Fprintf(cmd.OutOrStdout(), "\nAvailable updates:\n")
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "\nAvailable updates:\n")
 	for _, update := range updates {
+		ref := update.Ref
+		if ref == "" {
+			ref = "default branch"
+		}
+
+		line := fmt.Sprintf("  %s (%s)", update.URL, ref)
+		if update.LatestVersion != "" {
+			line += " → " + update.LatestVersion
+		}
+		if update.NewRef != "" {
+			line += fmt.Sprintf(" (major update, ref becomes %s)", update.NewRef)
+		}
+		if update.HasNewCommits() {
+			line += fmt.Sprintf(" %s → %s", shortCommit(update.CurrentCommit), shortCommit(update.LatestCommit))
+			if update.CommitsBehind > 0 {
+				line += fmt.Sprintf(", %d commit(s) behind", update.CommitsBehind)
+			}
+		}
 		if update.IsPinned && updateForce {
-			fmt.Fprintf(cmd.OutOrStdout(), "  %s (force update pinned source)\n", update.URL)
-		} else {
-			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", update.URL)
+			line += " (force update pinned source)"
+		}
+		fmt.Fprintln(out, line)
+
+		if len(update.NewerTags) > 0 {
+			fmt.Fprintf(out, "      newer tags: %s\n", strings.Join(update.NewerTags, ", "))
 		}
 	}
 }
 
-func updatePromptsfile(cfg *config.ExtendedConfig, updates []sourceUpdate) error {
-	// For unpinned sources that have new refs available,
-	// we might update the Promptsfile to point to new branches/tags
-	// This is a simplified implementation
+// shortCommit abbreviates a commit hash for display
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "(none)"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
 
-	// In a real implementation, this would:
-	// 1. Check if any sources need their refs updated in Promptsfile
-	// 2. Preserve formatting and comments
-	// 3. Write the updated file
+// updatePromptsfile rewrites the ref of every source with a planned major
+// update, in its sources: or rulesets: entry. The file is left untouched when
+// there are no major updates.
+func updatePromptsfile(path string, cfg *config.ExtendedConfig, selected []config.SourceCfg, updates []sourceUpdate) error {
+	changed := false
+	for _, update := range updates {
+		if update.NewRef == "" {
+			continue
+		}
+		for _, source := range selected {
+			if source.URL() != update.URL || source.Ruleset == "" {
+				continue
+			}
+			for i, ruleset := range cfg.Rulesets {
+				if ruleset == source.Ruleset {
+					cfg.Rulesets[i] = strings.Split(ruleset, "@")[0] + "@" + update.NewRef
+					changed = true
+				}
+			}
+		}
+		for i, source := range cfg.Sources {
+			if source.URL() == update.URL {
+				cfg.Sources[i].Ref = update.NewRef
+				changed = true
+			}
+		}
+	}
 
-	return nil
+	if !changed {
+		return nil
+	}
+	return writeConfig(path, cfg)
 }
 
-// gitOptions creates git options from command flags
-func gitOptions() []git.Option {
+// gitOptions creates git options from the --offline and --cache-dir flags
+// of a command
+func gitOptions(offline bool, cacheDir string) []git.Option {
 	opts := []git.Option{}
 
-	if updateOffline {
+	if offline {
 		opts = append(opts, git.WithOfflineMode())
 	}
 
-	if updateCacheDir != "" {
-		opts = append(opts, git.WithCacheDir(updateCacheDir))
+	if cacheDir != "" {
+		opts = append(opts, git.WithCacheDir(cacheDir))
 	}
 
 	return opts
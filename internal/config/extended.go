@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -9,9 +10,42 @@ import (
 
 // ExtendedConfig represents the full Promptsfile configuration
 type ExtendedConfig struct {
-	Sources  []string    `yaml:"sources"`
+	// Version is the schema version; Promptsfiles without one use the
+	// legacy schema, which is read the same way
+	Version int         `yaml:"version,omitempty"`
+	Sources []SourceCfg `yaml:"sources"`
+
+	// TrustedSources extends the allow-list; installing a source does not
+	// make it trusted
+	TrustedSources []Source `yaml:"trusted_sources,omitempty"`
+
+	Rulesets []string    `yaml:"rulesets,omitempty"` // source/pack@ref selections from named sources
 	Overlays []Overlay   `yaml:"overlays"`
 	Adapters AdaptersCfg `yaml:"adapters"`
+	Security SecurityCfg `yaml:"security"`
+
+	// Vars are the template variables substituted into prompt bodies
+	Vars map[string]interface{} `yaml:"vars,omitempty"`
+
+	// LocalVars are the variables of Promptsfile.local, which is never
+	// written back
+	LocalVars map[string]interface{} `yaml:"-"`
+}
+
+// Variables returns the template variables of a source: the Promptsfile
+// vars, then the source's own, then Promptsfile.local
+func (c *ExtendedConfig) Variables(source SourceCfg) map[string]interface{} {
+	vars := make(map[string]interface{}, len(c.Vars)+len(source.Vars)+len(c.LocalVars))
+	for name, value := range c.Vars {
+		vars[name] = value
+	}
+	for name, value := range source.Vars {
+		vars[name] = value
+	}
+	for name, value := range c.LocalVars {
+		vars[name] = value
+	}
+	return vars
 }
 
 // Overlay represents a prompt pack with a specific scope
@@ -20,21 +54,61 @@ type Overlay struct {
 	Source string `yaml:"source"`
 }
 
-// AdaptersCfg holds configuration for all adapters
+// AdaptersCfg holds configuration for all adapters. Other holds the blocks of
+// every other adapter, built in or provided by a plugin, keyed by name.
 type AdaptersCfg struct {
-	Cursor CursorCfg `yaml:"cursor"`
-	Claude ClaudeCfg `yaml:"claude"`
+	Cursor CursorCfg             `yaml:"cursor"`
+	Claude ClaudeCfg             `yaml:"claude"`
+	Other  map[string]AdapterCfg `yaml:",inline"`
+}
+
+// AdapterCfg holds the configuration of an adapter without a dedicated block
+type AdapterCfg struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Settings holds every other key of the block, e.g. options passed to a plugin
+	Settings map[string]interface{} `yaml:",inline"`
+}
+
+// Enabled returns the settings of each enabled adapter keyed by name
+func (a AdaptersCfg) Enabled() map[string]map[string]interface{} {
+	enabled := make(map[string]map[string]interface{})
+	if a.Cursor.Enabled {
+		enabled["cursor"] = map[string]interface{}{"naming": a.Cursor.Naming}
+	}
+	if a.Claude.Enabled {
+		enabled["claude"] = map[string]interface{}{"prefix": a.Claude.Prefix, "naming": a.Claude.Naming}
+	}
+	for name, other := range a.Other {
+		if other.Enabled {
+			settings := other.Settings
+			if settings == nil {
+				settings = map[string]interface{}{}
+			}
+			enabled[name] = settings
+		}
+	}
+	return enabled
 }
 
 // CursorCfg holds Cursor-specific configuration
 type CursorCfg struct {
-	Enabled bool `yaml:"enabled"`
+	Enabled bool   `yaml:"enabled"`
+	Naming  string `yaml:"naming,omitempty"` // flat (default), nested or path
 }
 
 // ClaudeCfg holds Claude-specific configuration
 type ClaudeCfg struct {
 	Enabled bool   `yaml:"enabled"`
 	Prefix  string `yaml:"prefix"`
+	Naming  string `yaml:"naming,omitempty"` // flat (default), nested or path
+}
+
+// SecurityCfg holds prompt security settings
+type SecurityCfg struct {
+	// Threshold is the highest prompt security level (low, medium or high)
+	// installed without a warning
+	Threshold string `yaml:"threshold"`
 }
 
 // Loader handles configuration loading
@@ -60,12 +134,40 @@ func (l *Loader) Load() (*ExtendedConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	if cfg.Version != 0 && cfg.Version != SchemaVersion {
+		return nil, fmt.Errorf("unsupported Promptsfile version %d (supported: %d)", cfg.Version, SchemaVersion)
+	}
+
+	// Promptsfile.local overrides variables for this checkout only
+	cfg.LocalVars, err = readLocalVars(filepath.Join(l.workspaceDir, "Promptsfile.local"))
+	if err != nil {
+		return nil, err
+	}
 
 	// Set defaults
-	if !cfg.Adapters.Cursor.Enabled && !cfg.Adapters.Claude.Enabled {
+	if len(cfg.Adapters.Enabled()) == 0 {
 		// If no adapters are explicitly configured, enable Cursor by default
 		cfg.Adapters.Cursor.Enabled = true
 	}
 
 	return &cfg, nil
 }
+
+// readLocalVars returns the vars: block of Promptsfile.local, if any
+func readLocalVars(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var local struct {
+		Vars map[string]interface{} `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(data, &local); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return local.Vars, nil
+}
@@ -13,15 +13,26 @@ import (
 // Source represents a trusted prompt source declared in configuration files.
 // Only the fields required for tasks 2.* are included here.
 type Source struct {
-	Name         string `yaml:"name"`
+	Name         string `yaml:"name,omitempty"`
 	Repo         string `yaml:"repo"`
 	ClaudePrefix string `yaml:"claude_prefix,omitempty"`
+
+	// File is the configuration file that declared the source
+	File string `yaml:"-"`
+
+	// Precedence is the position of File in the precedence chain, higher
+	// files override lower ones
+	Precedence int `yaml:"-"`
 }
 
 // Config aggregates all prompt-sync configuration that the application cares
 // about at load-time. For the MVP, we only expose the list of trusted sources.
 type Config struct {
 	Sources []Source
+
+	// Policy is the organisation policy file named in the user config. Only
+	// the user config may set it so projects cannot opt out of the policy.
+	Policy string
 }
 
 // FindPromptsfilePath locates the Promptsfile according to the following precedence:
@@ -62,24 +73,38 @@ func FindPromptsfilePath(workspaceDir string) (string, error) {
 //
 // Later files override earlier ones when they declare a source with the same
 // name. Duplicate names are considered the same logical source – the entry
-// appearing later in the precedence chain wins.
+// appearing later in the precedence chain wins. The Promptsfiles declare
+// trust under trusted_sources: since their sources: are the packs to
+// install; the user config also accepts sources:.
 func Load(projectDir string) (*Config, error) {
 	paths := []string{userConfigPath(), filepath.Join(projectDir, "Promptsfile"), filepath.Join(projectDir, "Promptsfile.local")}
 
 	sourceMap := make(map[string]Source)
-	for _, p := range paths {
-		if err := readSourcesFromFile(p, sourceMap); err != nil {
+	for precedence, p := range paths {
+		if err := readSourcesFromFile(p, precedence, precedence == 0, sourceMap); err != nil {
 			return nil, err
 		}
 	}
 
-	// Convert map → slice with predictable order (sorted by name for stability)
+	// Convert map → slice with predictable order (sorted by name, then repo,
+	// since sources without a name are keyed by their repo)
 	var cfg Config
 	for _, src := range sourceMap {
 		cfg.Sources = append(cfg.Sources, src)
 	}
 	// Stable ordering to avoid nondeterministic test failures.
-	sort.Slice(cfg.Sources, func(i, j int) bool { return cfg.Sources[i].Name < cfg.Sources[j].Name })
+	sort.SliceStable(cfg.Sources, func(i, j int) bool {
+		if cfg.Sources[i].Name != cfg.Sources[j].Name {
+			return cfg.Sources[i].Name < cfg.Sources[j].Name
+		}
+		return cfg.Sources[i].Repo < cfg.Sources[j].Repo
+	})
+
+	policy, err := readPolicyPath(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	cfg.Policy = policy
 
 	return &cfg, nil
 }
@@ -97,9 +122,11 @@ func userConfigPath() string {
 	return filepath.Join(home, ".prompt-sync", "config.yaml")
 }
 
-// readSourcesFromFile parses a YAML config file and merges its sources into dst.
-// Missing files are silently ignored so tests don't need to create every file.
-func readSourcesFromFile(path string, dst map[string]Source) error {
+// readSourcesFromFile parses a YAML config file at the given position in the
+// precedence chain and merges its trusted sources into dst. The sources:
+// block is only read when userConfig is set. Missing files are silently
+// ignored so tests don't need to create every file.
+func readSourcesFromFile(path string, precedence int, userConfig bool, dst map[string]Source) error {
 	if path == "" {
 		return nil
 	}
@@ -111,17 +138,60 @@ func readSourcesFromFile(path string, dst map[string]Source) error {
 		return err
 	}
 	var parsed struct {
-		Sources []Source `yaml:"sources"`
+		Sources        []yaml.Node `yaml:"sources"`
+		TrustedSources []yaml.Node `yaml:"trusted_sources"`
 	}
 	if err := yaml.Unmarshal(data, &parsed); err != nil {
-		return err
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	nodes := parsed.TrustedSources
+	if userConfig {
+		nodes = append(parsed.Sources, nodes...)
 	}
-	for _, s := range parsed.Sources {
-		dst[s.Name] = s // higher precedence overwrites
+	for _, node := range nodes {
+		// Plain string entries name no repository to trust
+		if node.Kind != yaml.MappingNode {
+			continue
+		}
+		var s Source
+		if err := node.Decode(&s); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		s.File, s.Precedence = path, precedence
+		key := s.Name
+		if key == "" {
+			key = s.Repo
+		}
+		dst[key] = s // higher precedence overwrites
 	}
 	return nil
 }
 
+// readPolicyPath returns the policy file named in a user config. Relative
+// paths are resolved against the directory of the config file.
+func readPolicyPath(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errorsIsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	var parsed struct {
+		Policy string `yaml:"policy"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if parsed.Policy == "" || filepath.IsAbs(parsed.Policy) {
+		return parsed.Policy, nil
+	}
+	return filepath.Join(filepath.Dir(path), parsed.Policy), nil
+}
+
 func errorsIsNotExist(err error) bool {
 	return err != nil && os.IsNotExist(err)
 }
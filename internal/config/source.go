@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the Promptsfile schema version written by prompt-sync
+const SchemaVersion = 1
+
+// SourceCfg is a source installed by the Promptsfile. It is written either as
+// a legacy "url#ref" string or as an object.
+type SourceCfg struct {
+	Name         string                 `yaml:"name,omitempty"`
+	Repo         string                 `yaml:"repo"`
+	Ref          string                 `yaml:"ref,omitempty"`
+	Subdirectory string                 `yaml:"subdirectory,omitempty"` // Pack directory within the repository
+	Adapters     AdapterFilter          `yaml:"adapters,omitempty"`
+	Prefix       string                 `yaml:"prefix,omitempty"` // File name prefix, e.g. of Claude commands
+	Vars         map[string]interface{} `yaml:"vars,omitempty"`
+
+	// Pack is the pack a ruleset selected, resolved within the subdirectory
+	// by the name in its pack.yaml or by path
+	Pack string `yaml:"-"`
+
+	// Ruleset is the rulesets: entry that selected the source, if any
+	Ruleset string `yaml:"-"`
+}
+
+// AdapterFilter limits the adapters that render a source
+type AdapterFilter struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Allows reports whether the named adapter renders the source
+func (f AdapterFilter) Allows(name string) bool {
+	for _, excluded := range f.Exclude {
+		if excluded == name {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, included := range f.Include {
+		if included == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSource parses a legacy source string of the form url[//subdirectory][#ref]
+func ParseSource(spec string) SourceCfg {
+	url, ref, _ := strings.Cut(spec, "#")
+	repo, subdirectory := SplitSubdirectory(url)
+	return SourceCfg{Repo: repo, Ref: ref, Subdirectory: subdirectory}
+}
+
+// SplitSubdirectory splits a source URL such as github.com/acme/prompts//go
+// into the repository URL and the pack directory within it
+func SplitSubdirectory(url string) (string, string) {
+	start := 0
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		start = idx + len("://")
+	}
+	idx := strings.Index(url[start:], "//")
+	if idx < 0 {
+		return url, ""
+	}
+	return url[:start+idx], url[start+idx+2:]
+}
+
+// URL identifies the source in the lock file: the repository URL followed
+// by //subdirectory/pack when the source is a directory or pack of the
+// repository
+func (s SourceCfg) URL() string {
+	dir := path.Join(s.Subdirectory, s.Pack)
+	if dir == "" {
+		return s.Repo
+	}
+	return s.Repo + "//" + dir
+}
+
+// String returns the source in the legacy url#ref form
+func (s SourceCfg) String() string {
+	if s.Ref == "" {
+		return s.URL()
+	}
+	return s.URL() + "#" + s.Ref
+}
+
+// UnmarshalYAML accepts both the legacy string form and objects
+func (s *SourceCfg) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = ParseSource(node.Value)
+		return nil
+	}
+
+	type plain SourceCfg
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Repo == "" {
+		return fmt.Errorf("line %d: source %q has no repo", node.Line, s.Name)
+	}
+	s.Subdirectory = strings.Trim(s.Subdirectory, "/")
+	return nil
+}
+
+// MarshalYAML writes sources that only name a repository and ref in the
+// legacy string form so existing Promptsfiles keep their shape
+func (s SourceCfg) MarshalYAML() (interface{}, error) {
+	if s.Name == "" && s.Prefix == "" && len(s.Vars) == 0 && len(s.Adapters.Include) == 0 && len(s.Adapters.Exclude) == 0 {
+		return s.String(), nil
+	}
+	type plain SourceCfg
+	return plain(s), nil
+}
+
+// Selected returns the sources to install: the sources of the Promptsfile
+// followed by the packs chosen by rulesets. A named source whose packs are
+// chosen by rulesets is only installed through them.
+func (c *ExtendedConfig) Selected(lookup func(name string) (Source, bool)) ([]SourceCfg, error) {
+	var rulesets []SourceCfg
+	viaRulesets := make(map[string]bool)
+	for _, ruleset := range c.Rulesets {
+		name, pack, ref, err := ParseRuleset(ruleset)
+		if err != nil {
+			return nil, err
+		}
+
+		source, err := c.NamedSource(name, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("ruleset %s: %w", ruleset, err)
+		}
+		viaRulesets[name] = true
+
+		source.Pack = pack
+		if ref != "" {
+			source.Ref = ref
+		}
+		source.Ruleset = ruleset
+		rulesets = append(rulesets, source)
+	}
+
+	var selected []SourceCfg
+	for _, source := range c.Sources {
+		if source.Name == "" || !viaRulesets[source.Name] {
+			selected = append(selected, source)
+		}
+	}
+	return append(selected, rulesets...), nil
+}
+
+// NamedSource returns the source a ruleset or pack dependency names: a named
+// source of the Promptsfile or, through lookup, a trusted source declared
+// elsewhere
+func (c *ExtendedConfig) NamedSource(name string, lookup func(name string) (Source, bool)) (SourceCfg, error) {
+	for _, source := range c.Sources {
+		if source.Name == name {
+			return source, nil
+		}
+	}
+
+	trusted, found := lookup(name)
+	if !found {
+		return SourceCfg{}, fmt.Errorf("unknown source %q", name)
+	}
+	if strings.HasSuffix(trusted.Repo, "*") {
+		return SourceCfg{}, fmt.Errorf("source %q trusts a namespace, not a repository", name)
+	}
+	return SourceCfg{Name: trusted.Name, Repo: trusted.Repo, Prefix: trusted.ClaudePrefix}, nil
+}
+
+// ParseRuleset splits a rulesets: entry of the form source/pack[@ref]
+func ParseRuleset(ruleset string) (name, pack, ref string, err error) {
+	selection, ref, _ := strings.Cut(ruleset, "@")
+	name, pack, ok := strings.Cut(selection, "/")
+	pack = strings.Trim(pack, "/")
+	if !ok || name == "" || pack == "" || strings.Contains(pack, "//") {
+		return "", "", "", fmt.Errorf("invalid ruleset %q: must be source/pack or source/pack@ref", ruleset)
+	}
+	if cleaned := path.Clean(pack); cleaned != pack || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", "", "", fmt.Errorf("invalid ruleset %q: pack must be a directory inside the source", ruleset)
+	}
+	return name, pack, ref, nil
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/managed"
 )
 
 // Issue represents a conflict or drift issue
@@ -30,10 +32,7 @@ func New(strictMode bool) *Detector {
 
 // ScanDirectory scans a directory for duplicate basenames and returns issues
 func (d *Detector) ScanDirectory(dir string) ([]Issue, error) {
-	var issues []Issue
-
-	// Track basenames to detect duplicates
-	basenames := make(map[string][]string)
+	var files []string
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -44,9 +43,8 @@ func (d *Detector) ScanDirectory(dir string) ([]Issue, error) {
 			return nil
 		}
 
-		basename := filepath.Base(path)
 		relPath, _ := filepath.Rel(dir, path)
-		basenames[basename] = append(basenames[basename], relPath)
+		files = append(files, relPath)
 
 		return nil
 	})
@@ -55,6 +53,21 @@ func (d *Detector) ScanDirectory(dir string) ([]Issue, error) {
 		return nil, fmt.Errorf("failed to scan directory: %w", err)
 	}
 
+	return d.ScanFiles(files), nil
+}
+
+// ScanFiles checks a list of paths for duplicate basenames and returns issues.
+// It lets callers check a directory's future contents before writing them.
+func (d *Detector) ScanFiles(files []string) []Issue {
+	var issues []Issue
+
+	// Track basenames to detect duplicates
+	basenames := make(map[string][]string)
+	for _, path := range files {
+		basename := filepath.Base(path)
+		basenames[basename] = append(basenames[basename], path)
+	}
+
 	// Check for duplicates
 	for basename, paths := range basenames {
 		if len(paths) > 1 {
@@ -68,7 +81,7 @@ func (d *Detector) ScanDirectory(dir string) ([]Issue, error) {
 		}
 	}
 
-	return issues, nil
+	return issues
 }
 
 // CheckDrift compares file hashes against expected hashes from lock file
@@ -105,6 +118,41 @@ func (d *Detector) CheckDrift(files map[string]string) ([]Issue, error) {
 	return issues, nil
 }
 
+// CheckSectionDrift compares the managed sections of files against the
+// hashes from the lock file
+func (d *Detector) CheckSectionDrift(files map[string]string) ([]Issue, error) {
+	var issues []Issue
+
+	for path, expectedHash := range files {
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		actualHash, ok := managed.Hash(content)
+		if !ok {
+			issues = append(issues, Issue{
+				Type:       "drift",
+				Path:       path,
+				Details:    "managed section missing",
+				IsCritical: true,
+			})
+			continue
+		}
+
+		if actualHash != expectedHash {
+			issues = append(issues, Issue{
+				Type:       "drift",
+				Path:       path,
+				Details:    fmt.Sprintf("managed section hash mismatch: expected %s, got %s", expectedHash, actualHash),
+				IsCritical: true,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
 // FilterCritical returns only critical issues if in strict mode
 func (d *Detector) FilterCritical(issues []Issue) []Issue {
 	if !d.strictMode {
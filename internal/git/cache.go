@@ -0,0 +1,131 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The cache holds one bare object store per repository URL and one immutable
+// snapshot per checked out commit:
+//
+//	<cache>/<name>-<hash>.git                   bare mirror of the remote
+//	<cache>/snapshots/<name>-<hash>/<commit>/   working tree at <commit>
+//
+// Snapshots are built in a temporary directory and renamed into place, so a
+// snapshot path always points at a complete checkout of its commit and is never
+// modified afterwards. Fetches into the object store are serialised with a
+// lock file so concurrent installs in different projects can share the cache.
+
+const (
+	// lockTimeout is how long to wait for another process to release the store
+	lockTimeout = 2 * time.Minute
+	// staleLockAge is the age after which a lock file is assumed to be abandoned
+	staleLockAge = 10 * time.Minute
+)
+
+// cacheName generates a deterministic, human-readable directory name for a repo URL.
+func cacheName(repoURL string) string {
+	// Create a hash of the URL for the directory name
+	h := sha256.Sum256([]byte(repoURL))
+	hash := hex.EncodeToString(h[:])[:12]
+
+	// Extract a human-readable name from the URL
+	name := repoURL
+	name = strings.TrimSuffix(name, ".git")
+	parts := strings.Split(name, "/")
+	if len(parts) >= 2 {
+		name = parts[len(parts)-2] + "-" + parts[len(parts)-1]
+	}
+
+	// Clean up the name
+	name = strings.ReplaceAll(name, ":", "-")
+	name = strings.ReplaceAll(name, "@", "-")
+
+	return name + "-" + hash
+}
+
+// storePath returns the location of the bare object store for a repo URL.
+func storePath(cacheDir, repoURL string) string {
+	return filepath.Join(cacheDir, cacheName(repoURL)+".git")
+}
+
+// snapshotPath returns the location of the checkout of a commit.
+func snapshotPath(cacheDir, repoURL, commit string) string {
+	return filepath.Join(cacheDir, "snapshots", cacheName(repoURL), commit)
+}
+
+// snapshotExists reports whether a complete snapshot has been published.
+func snapshotExists(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+// buildSnapshot creates a snapshot for a commit unless it already exists.
+// The build callback populates a temporary directory which is then renamed to
+// the final path. If another process publishes the same snapshot first, the
+// temporary copy is discarded.
+func buildSnapshot(path string, build func(tmpDir string) error) error {
+	if snapshotExists(path) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	if err := build(tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+
+	if err := os.Rename(tmpDir, path); err != nil {
+		os.RemoveAll(tmpDir)
+		if snapshotExists(path) {
+			return nil
+		}
+		return fmt.Errorf("publish snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// lockStore takes an exclusive lock on an object store and returns a function
+// that releases it. Locks older than staleLockAge are broken.
+func lockStore(store string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(store), 0755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+
+	lockPath := store + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("lock cache: %w", err)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for cache lock %s", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
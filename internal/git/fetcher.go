@@ -1,16 +1,18 @@
 package git
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
 // Options configures a GitFetcher instance.
@@ -22,24 +24,39 @@ type Options struct {
 // Fetcher defines the interface for Git repository operations.
 // Implementations may use go-git, exec git, or other backends.
 type Fetcher interface {
-	// Clone fetches a repository at the given ref and returns the local path.
-	// If already cached, it may return the existing path.
+	// Clone fetches a repository at the given ref and returns the path of the
+	// snapshot of the commit the ref points to. If already cached, the cached
+	// ref is used without contacting the remote.
 	Clone(repoURL, ref string) (string, error)
 
-	// Update pulls the latest changes for a repository at the given ref.
+	// Update fetches the latest changes for a repository into the cache.
 	// Returns error if the repo is not already cloned.
 	Update(repoURL, ref string) error
 
-	// CachedPath returns the local path for a cached repository, if it exists.
-	// The bool indicates whether the repo is cached.
+	// CachedPath returns the snapshot path for the commit the ref points to in
+	// the cache, if that snapshot exists. The bool indicates whether it does.
 	CachedPath(repoURL, ref string) (string, bool)
 
-	// CloneOrUpdate fetches a repository at the given ref and returns the local path and commit hash.
-	// If already cached, it updates and returns the existing path.
+	// CloneOrUpdate fetches a repository at the given ref and returns the snapshot path and commit hash.
+	// If already cached, it fetches first so branches move to the remote tip.
 	CloneOrUpdate(repoURL, ref string) (path string, commit string, err error)
+
+	// CheckoutCommit fetches a repository if needed and returns the snapshot of the exact commit.
+	// It is used to reproduce installs from the commits recorded in the lock file.
+	CheckoutCommit(repoURL, commit string) (path string, err error)
+
+	// ResolveRemote queries the remote for the commit the ref currently points to
+	// and the tags it advertises. Version ranges such as "^1.2" resolve to the
+	// highest matching tag. It does not modify any checkout.
+	ResolveRemote(repoURL, ref string) (*RemoteRef, error)
+
+	// CommitsBehind returns how many commits reachable from "to" are not
+	// reachable from "from", fetching missing history into the cache as needed.
+	CommitsBehind(repoURL, from, to string) (int, error)
 }
 
 // fetcher is the go-git implementation of the Fetcher interface.
+// Paths it returns are immutable per-commit snapshots, see cache.go.
 type fetcher struct {
 	options Options
 }
@@ -54,228 +71,401 @@ func NewFetcher(options ...Option) Fetcher {
 	return &fetcher{options: opts}
 }
 
-// repoPath generates a deterministic local path for a repo URL.
-func (f *fetcher) repoPath(repoURL string) string {
-	// Create a hash of the URL for the directory name
-	h := sha256.Sum256([]byte(repoURL))
-	hash := hex.EncodeToString(h[:])[:12]
+// storePath returns the bare object store for a repo URL.
+func (f *fetcher) storePath(repoURL string) string {
+	return storePath(f.options.CacheDir, repoURL)
+}
+
+// Clone fetches a repository at the given ref and returns the snapshot path.
+func (f *fetcher) Clone(repoURL, ref string) (string, error) {
+	path, _, err := f.checkout(repoURL, ref, false)
+	return path, err
+}
 
-	// Extract a human-readable name from the URL
-	name := repoURL
-	name = strings.TrimSuffix(name, ".git")
-	parts := strings.Split(name, "/")
-	if len(parts) >= 2 {
-		name = parts[len(parts)-2] + "-" + parts[len(parts)-1]
+// Update fetches the latest changes for a repository into the object store.
+func (f *fetcher) Update(repoURL, ref string) error {
+	if f.options.Offline {
+		return fmt.Errorf("offline mode: cannot update")
 	}
 
-	// Clean up the name
-	name = strings.ReplaceAll(name, ":", "-")
-	name = strings.ReplaceAll(name, "@", "-")
+	repo, err := git.PlainOpen(f.storePath(repoURL))
+	if err != nil {
+		return fmt.Errorf("repository not cloned: %w", err)
+	}
 
-	return filepath.Join(f.options.CacheDir, name+"-"+hash)
+	if err := f.fetch(repoURL, repo); err != nil {
+		return err
+	}
+
+	if _, err := f.resolveRef(repo, ref); err != nil {
+		return fmt.Errorf("ref not found: %s", ref)
+	}
+	return nil
 }
 
-// Clone fetches a repository at the given ref and returns the local path.
-func (f *fetcher) Clone(repoURL, ref string) (string, error) {
-	repoPath := f.repoPath(repoURL)
+// CachedPath returns the snapshot for the cached commit of a ref, if it exists.
+func (f *fetcher) CachedPath(repoURL, ref string) (string, bool) {
+	repo, err := git.PlainOpen(f.storePath(repoURL))
+	if err != nil {
+		return "", false
+	}
 
-	// Check if already cloned
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err == nil {
-		// Repository exists, checkout the ref
-		repo, err := git.PlainOpen(repoPath)
-		if err != nil {
-			return "", fmt.Errorf("open existing repo: %w", err)
-		}
+	hash, err := f.resolveRef(repo, ref)
+	if err != nil {
+		return "", false
+	}
 
-		if err := f.checkoutRef(repo, ref); err != nil {
-			return "", fmt.Errorf("checkout ref %s: %w", ref, err)
-		}
+	path := snapshotPath(f.options.CacheDir, repoURL, hash.String())
+	if !snapshotExists(path) {
+		return "", false
+	}
 
-		return repoPath, nil
+	return path, true
+}
+
+// CloneOrUpdate fetches a repository at the given ref and returns the snapshot path and commit hash.
+func (f *fetcher) CloneOrUpdate(repoURL, ref string) (string, string, error) {
+	return f.checkout(repoURL, ref, true)
+}
+
+// CheckoutCommit fetches a repository if needed and returns the snapshot of the exact commit.
+func (f *fetcher) CheckoutCommit(repoURL, commit string) (string, error) {
+	if !IsCommitHash(commit) {
+		return "", fmt.Errorf("invalid commit hash: %q", commit)
 	}
 
-	// In offline mode, we can't clone new repos
-	if f.options.Offline {
-		return "", fmt.Errorf("offline mode: repository not cached")
+	repo, _, err := f.openOrClone(repoURL)
+	if err != nil {
+		return "", err
 	}
 
-	// Ensure cache directory exists
-	if err := os.MkdirAll(f.options.CacheDir, 0755); err != nil {
-		return "", fmt.Errorf("create cache dir: %w", err)
+	hash, err := f.resolveCommit(repoURL, repo, commit)
+	if err != nil {
+		return "", err
 	}
 
-	// Clone the repository
-	cloneOpts := &git.CloneOptions{
-		URL:      repoURL,
-		Progress: nil, // Could add progress reporting later
+	return f.snapshot(repoURL, hash)
+}
+
+// checkout resolves a ref in the object store and returns the snapshot of its
+// commit. With refresh set, an existing store is fetched first so branches move
+// to the remote tip; otherwise the remote is only contacted for unknown refs.
+func (f *fetcher) checkout(repoURL, ref string, refresh bool) (string, string, error) {
+	repo, cloned, err := f.openOrClone(repoURL)
+	if err != nil {
+		return "", "", err
 	}
 
-	// If ref looks like a branch, clone it directly
-	if !strings.HasPrefix(ref, "v") && !isCommitHash(ref) {
-		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(ref)
+	if refresh && !cloned && !f.options.Offline {
+		// Fall back to the cached refs when the remote is unreachable
+		_ = f.fetch(repoURL, repo)
 	}
 
-	repo, err := git.PlainClone(repoPath, false, cloneOpts)
+	hash, err := f.resolveRef(repo, ref)
+	if err != nil && !refresh && !cloned && !f.options.Offline {
+		if fetchErr := f.fetch(repoURL, repo); fetchErr == nil {
+			hash, err = f.resolveRef(repo, ref)
+		}
+	}
 	if err != nil {
-		// Clean up partial clone
-		os.RemoveAll(repoPath)
-		return "", fmt.Errorf("clone repository: %w", err)
+		return "", "", fmt.Errorf("checkout ref %s: ref not found: %s", ref, ref)
 	}
 
-	// Checkout the specific ref if needed
-	if err := f.checkoutRef(repo, ref); err != nil {
-		// Clean up failed clone
-		os.RemoveAll(repoPath)
-		return "", fmt.Errorf("checkout ref %s: %w", ref, err)
+	path, err := f.snapshot(repoURL, hash)
+	if err != nil {
+		return "", "", err
 	}
 
-	return repoPath, nil
+	return path, hash.String(), nil
 }
 
-// checkoutRef checks out a specific ref (branch, tag, or commit).
-func (f *fetcher) checkoutRef(repo *git.Repository, ref string) error {
-	w, err := repo.Worktree()
+// resolveRef resolves a branch, tag or commit in the object store. An empty
+// ref resolves to the remote's default branch.
+func (f *fetcher) resolveRef(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	if ref == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return head.Hash(), nil
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
 	if err != nil {
-		return err
+		return plumbing.ZeroHash, err
 	}
+	return *hash, nil
+}
 
-	// Try as a tag first
-	tagRef, err := repo.Tag(ref)
-	if err == nil {
-		return w.Checkout(&git.CheckoutOptions{
-			Hash: tagRef.Hash(),
-		})
-	}
-
-	// Try as a branch
-	branchRef := plumbing.NewBranchReferenceName(ref)
-	if _, err := repo.Reference(branchRef, false); err == nil {
-		return w.Checkout(&git.CheckoutOptions{
-			Branch: branchRef,
-		})
-	}
-
-	// Try as a commit hash
-	if isCommitHash(ref) {
-		return w.Checkout(&git.CheckoutOptions{
-			Hash: plumbing.NewHash(ref),
-		})
-	}
-
-	// Fetch all refs and try again
-	if !f.options.Offline {
-		remote, err := repo.Remote("origin")
-		if err == nil {
-			err = remote.Fetch(&git.FetchOptions{
-				RefSpecs: []config.RefSpec{
-					config.RefSpec("+refs/heads/*:refs/remotes/origin/*"),
-					config.RefSpec("+refs/tags/*:refs/tags/*"),
-				},
-			})
-			if err != nil && err != git.NoErrAlreadyUpToDate {
-				return fmt.Errorf("fetch refs: %w", err)
-			}
+// snapshot returns the immutable working tree of a commit, creating it from
+// the object store if needed. Like git clone --shared, the snapshot borrows the
+// store's objects through alternates, so commits no longer reachable from any
+// ref can still be checked out.
+func (f *fetcher) snapshot(repoURL string, hash plumbing.Hash) (string, error) {
+	path := snapshotPath(f.options.CacheDir, repoURL, hash.String())
 
-			// Try tag again after fetch
-			tagRef, err := repo.Tag(ref)
-			if err == nil {
-				return w.Checkout(&git.CheckoutOptions{
-					Hash: tagRef.Hash(),
-				})
-			}
+	store, err := filepath.Abs(f.storePath(repoURL))
+	if err != nil {
+		return "", err
+	}
+
+	err = buildSnapshot(path, func(tmpDir string) error {
+		storage := filesystem.NewStorageWithOptions(
+			osfs.New(filepath.Join(tmpDir, git.GitDirName)),
+			cache.NewObjectLRUDefault(),
+			filesystem.Options{AlternatesFS: osfs.New("/", osfs.WithBoundOS())},
+		)
+		repo, err := git.Init(storage, osfs.New(tmpDir))
+		if err != nil {
+			return fmt.Errorf("create snapshot: %w", err)
+		}
+		if err := storage.AddAlternate(store); err != nil {
+			return fmt.Errorf("create snapshot: %w", err)
 		}
+
+		w, err := repo.Worktree()
+		if err != nil {
+			return err
+		}
+
+		if err := w.Checkout(&git.CheckoutOptions{Hash: hash, Force: true}); err != nil {
+			return fmt.Errorf("checkout commit %s: %w", hash, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	return fmt.Errorf("ref not found: %s", ref)
+	return path, nil
 }
 
-// Update pulls the latest changes for a repository at the given ref.
-func (f *fetcher) Update(repoURL, ref string) error {
+// ResolveRemote queries the remote for the commit the ref currently points to.
+func (f *fetcher) ResolveRemote(repoURL, ref string) (*RemoteRef, error) {
 	if f.options.Offline {
-		return fmt.Errorf("offline mode: cannot update")
+		return nil, fmt.Errorf("offline mode: cannot query remote")
 	}
 
-	repoPath := f.repoPath(repoURL)
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
 
-	repo, err := git.PlainOpen(repoPath)
+	advertised, err := remote.List(&git.ListOptions{PeelingOption: git.AppendPeeled})
 	if err != nil {
-		return fmt.Errorf("repository not cloned: %w", err)
+		return nil, fmt.Errorf("list remote refs: %w", err)
 	}
 
-	// Fetch latest changes
-	remote, err := repo.Remote("origin")
+	refs := make(map[string]string)
+	var headTarget plumbing.ReferenceName
+	for _, r := range advertised {
+		if r.Type() == plumbing.SymbolicReference {
+			if r.Name() == plumbing.HEAD {
+				headTarget = r.Target()
+			}
+			continue
+		}
+		refs[r.Name().String()] = r.Hash().String()
+	}
+	if _, ok := refs["HEAD"]; !ok && headTarget != "" {
+		refs["HEAD"] = refs[headTarget.String()]
+	}
+
+	return newRemoteRef(ref, refs)
+}
+
+// CommitsBehind returns how many commits reachable from "to" are not reachable from "from".
+func (f *fetcher) CommitsBehind(repoURL, from, to string) (int, error) {
+	repo, _, err := f.openOrClone(repoURL)
+	if err != nil {
+		return 0, err
+	}
+
+	toHash, err := f.resolveCommit(repoURL, repo, to)
+	if err != nil {
+		return 0, err
+	}
+	fromHash, err := f.resolveCommit(repoURL, repo, from)
 	if err != nil {
-		return fmt.Errorf("get remote: %w", err)
+		return 0, err
 	}
 
-	err = remote.Fetch(&git.FetchOptions{
-		RefSpecs: []config.RefSpec{
-			config.RefSpec("+refs/heads/*:refs/remotes/origin/*"),
-			config.RefSpec("+refs/tags/*:refs/tags/*"),
-		},
+	// Collect everything the current commit already contains
+	seen := make(map[plumbing.Hash]bool)
+	fromCommit, err := repo.CommitObject(fromHash)
+	if err != nil {
+		return 0, fmt.Errorf("read commit %s: %w", from, err)
+	}
+	err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
 	})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("fetch: %w", err)
+	if err != nil {
+		return 0, fmt.Errorf("walk history of %s: %w", from, err)
 	}
 
-	// Checkout the ref again to get latest
-	return f.checkoutRef(repo, ref)
+	toCommit, err := repo.CommitObject(toHash)
+	if err != nil {
+		return 0, fmt.Errorf("read commit %s: %w", to, err)
+	}
+
+	count := 0
+	err = object.NewCommitPreorderIter(toCommit, seen, nil).ForEach(func(c *object.Commit) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walk history of %s: %w", to, err)
+	}
+
+	return count, nil
 }
 
-// CachedPath returns the local path for a cached repository, if it exists.
-func (f *fetcher) CachedPath(repoURL, ref string) (string, bool) {
-	repoPath := f.repoPath(repoURL)
+// storeRefSpecs mirror branches and tags into the bare object store
+var storeRefSpecs = []config.RefSpec{
+	config.RefSpec("+refs/heads/*:refs/heads/*"),
+	config.RefSpec("+refs/tags/*:refs/tags/*"),
+}
 
-	// Check if the repository exists
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
-		return "", false
+// openOrClone opens the object store, creating it with a full fetch if it is
+// not cached yet. The bool reports whether the store was just created.
+func (f *fetcher) openOrClone(repoURL string) (*git.Repository, bool, error) {
+	store := f.storePath(repoURL)
+
+	if repo, err := git.PlainOpen(store); err == nil {
+		return repo, false, nil
 	}
 
-	// Verify the ref is checked out
-	repo, err := git.PlainOpen(repoPath)
+	if f.options.Offline {
+		return nil, false, fmt.Errorf("offline mode: repository not cached")
+	}
+
+	unlock, err := lockStore(store)
 	if err != nil {
-		return "", false
+		return nil, false, err
 	}
+	defer unlock()
 
-	// For now, just return true if the repo exists
-	// Could verify the exact ref is checked out
-	_ = repo
+	// Another process may have created the store while we waited for the lock
+	if repo, err := git.PlainOpen(store); err == nil {
+		return repo, false, nil
+	}
+
+	tmpDir := store + ".tmp"
+	os.RemoveAll(tmpDir)
+
+	if err := f.initStore(tmpDir, repoURL); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, false, fmt.Errorf("clone repository: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, store); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, false, fmt.Errorf("clone repository: %w", err)
+	}
+
+	repo, err := git.PlainOpen(store)
+	if err != nil {
+		return nil, false, fmt.Errorf("open repository: %w", err)
+	}
 
-	return repoPath, true
+	return repo, true, nil
 }
 
-// CloneOrUpdate fetches a repository at the given ref and returns the local path and commit hash.
-func (f *fetcher) CloneOrUpdate(repoURL, ref string) (string, string, error) {
-	// First try to clone (which will reuse existing if cached)
-	path, err := f.Clone(repoURL, ref)
+// initStore creates a bare repository with every branch and tag of the remote
+// and points HEAD at the remote's default branch.
+func (f *fetcher) initStore(dir, repoURL string) error {
+	repo, err := git.PlainInit(dir, true)
 	if err != nil {
-		return "", "", err
+		return err
+	}
+
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name:  "origin",
+		URLs:  []string{repoURL},
+		Fetch: storeRefSpecs,
+	})
+	if err != nil {
+		return err
+	}
+
+	advertised, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return err
 	}
 
-	// If not offline and repo already existed, try to update
-	if !f.options.Offline {
-		if cached, _ := f.CachedPath(repoURL, ref); cached != "" {
-			// Ignore update errors in case we're on a detached head
-			_ = f.Update(repoURL, ref)
+	err = remote.Fetch(&git.FetchOptions{RefSpecs: storeRefSpecs, Tags: git.AllTags})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	// Point HEAD at the default branch of the remote
+	var headHash plumbing.Hash
+	for _, r := range advertised {
+		if r.Name() != plumbing.HEAD {
+			continue
+		}
+		if r.Type() == plumbing.SymbolicReference {
+			return repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, r.Target()))
+		}
+		headHash = r.Hash()
+	}
+	for _, r := range advertised {
+		if r.Name().IsBranch() && r.Hash() == headHash {
+			return repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, r.Name()))
 		}
 	}
 
-	// Get the current commit hash
-	repo, err := git.PlainOpen(path)
+	return nil
+}
+
+// fetch updates the branches and tags of the object store.
+func (f *fetcher) fetch(repoURL string, repo *git.Repository) error {
+	unlock, err := lockStore(f.storePath(repoURL))
 	if err != nil {
-		return path, "", fmt.Errorf("open repo to get commit: %w", err)
+		return err
+	}
+	defer unlock()
+
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   storeRefSpecs,
+		Tags:       git.AllTags,
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("fetch: %w", err)
+	}
+
+	return nil
+}
+
+// resolveCommit finds a commit object in the object store, fetching from the
+// remote when the commit is not available locally.
+func (f *fetcher) resolveCommit(repoURL string, repo *git.Repository, commit string) (plumbing.Hash, error) {
+	if hash, err := repo.ResolveRevision(plumbing.Revision(commit)); err == nil {
+		return *hash, nil
+	}
+
+	if f.options.Offline {
+		return plumbing.ZeroHash, fmt.Errorf("offline mode: commit %s not in cache", commit)
+	}
+
+	if err := f.fetch(repoURL, repo); err != nil {
+		return plumbing.ZeroHash, err
 	}
 
-	head, err := repo.Head()
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
 	if err != nil {
-		return path, "", fmt.Errorf("get HEAD commit: %w", err)
+		return plumbing.ZeroHash, fmt.Errorf("commit not found: %s", commit)
 	}
 
-	return path, head.Hash().String(), nil
+	return *hash, nil
 }
 
-// isCommitHash checks if a string looks like a git commit hash.
-func isCommitHash(s string) bool {
+// IsCommitHash reports whether a string looks like an abbreviated or full git
+// commit hash.
+func IsCommitHash(s string) bool {
 	if len(s) < 7 || len(s) > 40 {
 		return false
 	}
@@ -2,17 +2,15 @@ package git
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 )
 
 // execFetcher uses the system git binary for operations.
 // This is useful for large repos where go-git might be slower.
+// Snapshots share the objects of the store through git alternates.
 type execFetcher struct {
 	options Options
 }
@@ -27,25 +25,9 @@ func NewExecFetcher(options ...Option) Fetcher {
 	return &execFetcher{options: opts}
 }
 
-// repoPath generates a deterministic local path for a repo URL.
-func (f *execFetcher) repoPath(repoURL string) string {
-	// Create a hash of the URL for the directory name
-	h := sha256.Sum256([]byte(repoURL))
-	hash := hex.EncodeToString(h[:])[:12]
-
-	// Extract a human-readable name from the URL
-	name := repoURL
-	name = strings.TrimSuffix(name, ".git")
-	parts := strings.Split(name, "/")
-	if len(parts) >= 2 {
-		name = parts[len(parts)-2] + "-" + parts[len(parts)-1]
-	}
-
-	// Clean up the name
-	name = strings.ReplaceAll(name, ":", "-")
-	name = strings.ReplaceAll(name, "@", "-")
-
-	return filepath.Join(f.options.CacheDir, name+"-"+hash)
+// storePath returns the bare object store for a repo URL.
+func (f *execFetcher) storePath(repoURL string) string {
+	return storePath(f.options.CacheDir, repoURL)
 }
 
 // runGit runs a git command and returns the output.
@@ -67,150 +49,280 @@ func (f *execFetcher) runGit(dir string, args ...string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-// Clone fetches a repository at the given ref and returns the local path.
+// Clone fetches a repository at the given ref and returns the snapshot path.
 func (f *execFetcher) Clone(repoURL, ref string) (string, error) {
-	repoPath := f.repoPath(repoURL)
-
-	// Check if already cloned
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err == nil {
-		// Repository exists, fetch and checkout the ref
-		if !f.options.Offline {
-			// Fetch latest
-			if _, err := f.runGit(repoPath, "fetch", "--all", "--tags"); err != nil {
-				// Ignore fetch errors, might still work with local refs
-			}
-		}
+	path, _, err := f.checkout(repoURL, ref, false)
+	return path, err
+}
 
-		// Checkout the ref
-		if _, err := f.runGit(repoPath, "checkout", ref); err != nil {
-			return "", fmt.Errorf("checkout ref %s: %w", ref, err)
-		}
+// Update fetches the latest changes for a repository into the object store.
+func (f *execFetcher) Update(repoURL, ref string) error {
+	if f.options.Offline {
+		return fmt.Errorf("offline mode: cannot update")
+	}
+
+	store := f.storePath(repoURL)
+
+	// Check if repository exists
+	if _, err := os.Stat(store); err != nil {
+		return fmt.Errorf("repository not cloned")
+	}
 
-		return repoPath, nil
+	if err := f.fetch(store); err != nil {
+		return err
 	}
 
-	// In offline mode, we can't clone new repos
-	if f.options.Offline {
-		return "", fmt.Errorf("offline mode: repository not cached")
+	if _, err := f.resolveRef(store, ref); err != nil {
+		return fmt.Errorf("ref not found: %s", ref)
+	}
+	return nil
+}
+
+// CachedPath returns the snapshot for the cached commit of a ref, if it exists.
+func (f *execFetcher) CachedPath(repoURL, ref string) (string, bool) {
+	store := f.storePath(repoURL)
+
+	// Check if the repository exists
+	if _, err := os.Stat(store); err != nil {
+		return "", false
+	}
+
+	commit, err := f.resolveRef(store, ref)
+	if err != nil {
+		return "", false
+	}
+
+	path := snapshotPath(f.options.CacheDir, repoURL, commit)
+	if !snapshotExists(path) {
+		return "", false
+	}
+
+	return path, true
+}
+
+// CloneOrUpdate fetches a repository at the given ref and returns the snapshot path and commit hash.
+func (f *execFetcher) CloneOrUpdate(repoURL, ref string) (string, string, error) {
+	return f.checkout(repoURL, ref, true)
+}
+
+// CheckoutCommit fetches a repository if needed and returns the snapshot of the exact commit.
+func (f *execFetcher) CheckoutCommit(repoURL, commit string) (string, error) {
+	if !IsCommitHash(commit) {
+		return "", fmt.Errorf("invalid commit hash: %q", commit)
 	}
 
-	// Ensure cache directory exists
-	if err := os.MkdirAll(f.options.CacheDir, 0755); err != nil {
-		return "", fmt.Errorf("create cache dir: %w", err)
+	store, _, err := f.ensureStore(repoURL)
+	if err != nil {
+		return "", err
 	}
 
-	// Clone the repository with shallow clone for performance
-	args := []string{"clone", "--depth", "1"}
+	if err := f.ensureCommit(store, commit); err != nil {
+		return "", err
+	}
 
-	// If ref is a branch, clone it directly
-	if !isCommitHash(ref) && !strings.HasPrefix(ref, "v") {
-		args = append(args, "--branch", ref)
+	// Expand abbreviated hashes so the snapshot path is canonical
+	full, err := f.runGit(store, "rev-parse", "--verify", commit+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("commit not found: %s", commit)
 	}
 
-	args = append(args, repoURL, repoPath)
+	return f.snapshot(repoURL, full)
+}
 
-	if _, err := f.runGit("", args...); err != nil {
-		// Clean up partial clone
-		os.RemoveAll(repoPath)
+// checkout resolves a ref in the object store and returns the snapshot of its
+// commit. With refresh set, an existing store is fetched first so branches move
+// to the remote tip; otherwise the remote is only contacted for unknown refs.
+func (f *execFetcher) checkout(repoURL, ref string, refresh bool) (string, string, error) {
+	store, cloned, err := f.ensureStore(repoURL)
+	if err != nil {
+		return "", "", err
+	}
 
-		// Try full clone if shallow clone failed
-		args = []string{"clone", repoURL, repoPath}
-		if _, err := f.runGit("", args...); err != nil {
-			return "", fmt.Errorf("clone repository: %w", err)
+	if refresh && !cloned && !f.options.Offline {
+		// Fall back to the cached refs when the remote is unreachable
+		_ = f.fetch(store)
+	}
+
+	commit, err := f.resolveRef(store, ref)
+	if err != nil && !refresh && !cloned && !f.options.Offline {
+		if fetchErr := f.fetch(store); fetchErr == nil {
+			commit, err = f.resolveRef(store, ref)
 		}
 	}
+	if err != nil {
+		return "", "", fmt.Errorf("checkout ref %s: ref not found: %s", ref, ref)
+	}
+
+	path, err := f.snapshot(repoURL, commit)
+	if err != nil {
+		return "", "", err
+	}
+
+	return path, commit, nil
+}
+
+// resolveRef resolves a branch, tag or commit in the object store. An empty
+// ref resolves to the remote's default branch.
+func (f *execFetcher) resolveRef(store, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return f.runGit(store, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+}
+
+// snapshot returns the immutable working tree of a commit, creating it from
+// the object store if needed.
+func (f *execFetcher) snapshot(repoURL, commit string) (string, error) {
+	path := snapshotPath(f.options.CacheDir, repoURL, commit)
 
-	// Fetch all tags
-	if _, err := f.runGit(repoPath, "fetch", "--tags"); err != nil {
-		// Ignore tag fetch errors
-	}
-
-	// Checkout the specific ref if needed
-	if _, err := f.runGit(repoPath, "checkout", ref); err != nil {
-		// If checkout fails, try fetching the ref first
-		if _, err := f.runGit(repoPath, "fetch", "origin", ref); err == nil {
-			if _, err := f.runGit(repoPath, "checkout", ref); err != nil {
-				// Clean up failed clone
-				os.RemoveAll(repoPath)
-				return "", fmt.Errorf("checkout ref %s: %w", ref, err)
-			}
-		} else {
-			// Clean up failed clone
-			os.RemoveAll(repoPath)
-			return "", fmt.Errorf("checkout ref %s: %w", ref, err)
+	err := buildSnapshot(path, func(tmpDir string) error {
+		if _, err := f.runGit("", "clone", "--shared", "--no-checkout", f.storePath(repoURL), tmpDir); err != nil {
+			return fmt.Errorf("create snapshot: %w", err)
 		}
+		if _, err := f.runGit(tmpDir, "checkout", "--force", "--detach", commit); err != nil {
+			return fmt.Errorf("checkout commit %s: %w", commit, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	return repoPath, nil
+	return path, nil
 }
 
-// Update pulls the latest changes for a repository at the given ref.
-func (f *execFetcher) Update(repoURL, ref string) error {
+// ResolveRemote queries the remote for the commit the ref currently points to.
+func (f *execFetcher) ResolveRemote(repoURL, ref string) (*RemoteRef, error) {
 	if f.options.Offline {
-		return fmt.Errorf("offline mode: cannot update")
+		return nil, fmt.Errorf("offline mode: cannot query remote")
 	}
 
-	repoPath := f.repoPath(repoURL)
+	output, err := f.runGit("", "ls-remote", repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("list remote refs: %w", err)
+	}
 
-	// Check if repository exists
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
-		return fmt.Errorf("repository not cloned")
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs[fields[1]] = fields[0]
 	}
 
-	// Fetch latest changes
-	if _, err := f.runGit(repoPath, "fetch", "--all", "--tags"); err != nil {
-		return fmt.Errorf("fetch: %w", err)
+	return newRemoteRef(ref, refs)
+}
+
+// CommitsBehind returns how many commits reachable from "to" are not reachable from "from".
+func (f *execFetcher) CommitsBehind(repoURL, from, to string) (int, error) {
+	store, _, err := f.ensureStore(repoURL)
+	if err != nil {
+		return 0, err
 	}
 
-	// Checkout the ref to get latest
-	if _, err := f.runGit(repoPath, "checkout", ref); err != nil {
-		// Try to reset to origin/ref if it's a branch
-		if _, err := f.runGit(repoPath, "reset", "--hard", "origin/"+ref); err != nil {
-			return fmt.Errorf("checkout ref %s: %w", ref, err)
+	for _, commit := range []string{to, from} {
+		if err := f.ensureCommit(store, commit); err != nil {
+			return 0, err
 		}
 	}
 
-	return nil
+	output, err := f.runGit(store, "rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, fmt.Errorf("count commits: %w", err)
+	}
+
+	var count int
+	if _, err := fmt.Sscanf(output, "%d", &count); err != nil {
+		return 0, fmt.Errorf("parse commit count %q: %w", output, err)
+	}
+
+	return count, nil
 }
 
-// CachedPath returns the local path for a cached repository, if it exists.
-func (f *execFetcher) CachedPath(repoURL, ref string) (string, bool) {
-	repoPath := f.repoPath(repoURL)
+// ensureStore returns the object store, creating it with a full bare clone if
+// it is not cached yet. The bool reports whether the store was just created.
+func (f *execFetcher) ensureStore(repoURL string) (string, bool, error) {
+	store := f.storePath(repoURL)
 
-	// Check if the repository exists
-	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
-		return "", false
+	if _, err := os.Stat(store); err == nil {
+		return store, false, nil
 	}
 
-	// Verify we can get the current ref
-	if _, err := f.runGit(repoPath, "rev-parse", "HEAD"); err != nil {
-		return "", false
+	if f.options.Offline {
+		return "", false, fmt.Errorf("offline mode: repository not cached")
 	}
 
-	return repoPath, true
+	unlock, err := lockStore(store)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	// Another process may have created the store while we waited for the lock
+	if _, err := os.Stat(store); err == nil {
+		return store, false, nil
+	}
+
+	tmpDir := store + ".tmp"
+	os.RemoveAll(tmpDir)
+
+	if _, err := f.runGit("", "clone", "--bare", repoURL, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", false, fmt.Errorf("clone repository: %w", err)
+	}
+
+	// Bare clones do not configure a fetch refspec, mirror branches on fetch
+	if _, err := f.runGit(tmpDir, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", false, fmt.Errorf("configure repository: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, store); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", false, fmt.Errorf("clone repository: %w", err)
+	}
+
+	return store, true, nil
 }
 
-// CloneOrUpdate fetches a repository at the given ref and returns the local path and commit hash.
-func (f *execFetcher) CloneOrUpdate(repoURL, ref string) (string, string, error) {
-	// First try to clone (which will reuse existing if cached)
-	path, err := f.Clone(repoURL, ref)
+// fetch updates the branches and tags of the object store.
+func (f *execFetcher) fetch(store string) error {
+	unlock, err := lockStore(store)
 	if err != nil {
-		return "", "", err
+		return err
 	}
+	defer unlock()
 
-	// If not offline and repo already existed, try to update
-	if !f.options.Offline {
-		if cached, _ := f.CachedPath(repoURL, ref); cached != "" {
-			// Ignore update errors in case we're on a detached head
-			_ = f.Update(repoURL, ref)
-		}
+	if _, err := f.runGit(store, "fetch", "--prune", "--tags", "origin"); err != nil {
+		return fmt.Errorf("fetch: %w", err)
 	}
+	return nil
+}
 
-	// Get the current commit hash
-	commitHash, err := f.runGit(path, "rev-parse", "HEAD")
-	if err != nil {
-		return path, "", fmt.Errorf("get HEAD commit: %w", err)
+// ensureCommit makes sure the commit object is present in the object store,
+// fetching from the remote when it is missing.
+func (f *execFetcher) ensureCommit(store, commit string) error {
+	if f.hasCommit(store, commit) {
+		return nil
+	}
+
+	if f.options.Offline {
+		return fmt.Errorf("offline mode: commit %s not in cache", commit)
+	}
+
+	if err := f.fetch(store); err != nil {
+		return err
+	}
+	if !f.hasCommit(store, commit) {
+		return fmt.Errorf("commit not found: %s", commit)
 	}
 
-	return path, commitHash, nil
+	return nil
+}
+
+// hasCommit reports whether the commit object exists in the object store.
+func (f *execFetcher) hasCommit(store, commit string) bool {
+	_, err := f.runGit(store, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
 }
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/semver"
+)
+
+// RemoteRef describes where a ref currently points on the remote repository.
+type RemoteRef struct {
+	Ref     string            // Ref that was resolved (empty means the default branch)
+	Commit  string            // Commit the ref points to on the remote
+	Version string            // Tag selected when the ref is a version range
+	Tags    map[string]string // Every tag on the remote, mapped to the commit it points to
+}
+
+// TagNames returns the names of all remote tags in lexical order.
+func (r *RemoteRef) TagNames() []string {
+	names := make([]string, 0, len(r.Tags))
+	for name := range r.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newRemoteRef resolves ref against the references advertised by a remote.
+// The refs map uses full reference names as keys ("HEAD", "refs/heads/main",
+// "refs/tags/v1.0.0" and peeled "refs/tags/v1.0.0^{}") and commit hashes as values.
+func newRemoteRef(ref string, refs map[string]string) (*RemoteRef, error) {
+	remote := &RemoteRef{
+		Ref:  ref,
+		Tags: make(map[string]string),
+	}
+
+	for name, hash := range refs {
+		if !strings.HasPrefix(name, "refs/tags/") || strings.HasSuffix(name, "^{}") {
+			continue
+		}
+		// Annotated tags advertise the commit they point to as a peeled ref
+		if peeled, ok := refs[name+"^{}"]; ok {
+			hash = peeled
+		}
+		remote.Tags[strings.TrimPrefix(name, "refs/tags/")] = hash
+	}
+
+	if semver.IsConstraint(ref) {
+		tag, err := remote.matchConstraint(ref)
+		if err != nil {
+			return nil, err
+		}
+		remote.Version = tag
+		remote.Commit = remote.Tags[tag]
+		return remote, nil
+	}
+
+	switch {
+	case ref == "":
+		remote.Commit = refs["HEAD"]
+	case remote.Tags[ref] != "":
+		remote.Commit = remote.Tags[ref]
+	case refs["refs/heads/"+ref] != "":
+		remote.Commit = refs["refs/heads/"+ref]
+	case IsCommitHash(ref):
+		// Commits never move, the remote tip is the commit itself
+		remote.Commit = ref
+	}
+
+	if remote.Commit == "" {
+		return nil, fmt.Errorf("ref not found on remote: %s", ref)
+	}
+
+	return remote, nil
+}
+
+// matchConstraint returns the highest tag satisfying a version range
+func (r *RemoteRef) matchConstraint(ref string) (string, error) {
+	constraint, err := semver.ParseConstraint(ref)
+	if err != nil {
+		return "", err
+	}
+
+	best, ok := constraint.Best(semver.ParseAll(r.TagNames()))
+	if !ok {
+		return "", fmt.Errorf("no tag matches version range %s", ref)
+	}
+	return best.Original, nil
+}
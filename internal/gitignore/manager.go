@@ -56,6 +56,11 @@ func (m *Manager) Update(patterns []string) error {
 	return nil
 }
 
+// Path returns the location of the managed .gitignore
+func (m *Manager) Path() string {
+	return filepath.Join(m.workspaceDir, ".gitignore")
+}
+
 // Verify checks if the managed block exists and is up to date
 func (m *Manager) Verify(expectedPatterns []string) (bool, error) {
 	gitignorePath := filepath.Join(m.workspaceDir, ".gitignore")
@@ -16,10 +16,44 @@ import (
 
 // Source represents a locked source in the lock file
 type Source struct {
-	URL    string `yaml:"url"`
-	Ref    string `yaml:"ref,omitempty"`
-	Commit string `yaml:"commit"`
-	Files  []File `yaml:"files"`
+	URL      string         `yaml:"url"`
+	Ref      string         `yaml:"ref,omitempty"`
+	Version  string         `yaml:"version,omitempty"` // Tag selected when Ref is a version range
+	Scope    string         `yaml:"scope,omitempty"`   // Overlay scope: org, project or personal
+	Commit   string         `yaml:"commit"`
+	Files    []File         `yaml:"files"`
+	Shadowed []ShadowedFile `yaml:"shadowed,omitempty"` // Files replaced by a source in a higher scope
+	Skipped  []SkippedFile  `yaml:"skipped,omitempty"`  // Prompts whose targets leave out an adapter
+
+	// PackVersion is the version declared in the pack's pack.yaml
+	PackVersion string `yaml:"pack_version,omitempty"`
+	// Dependencies are the packs the pack's pack.yaml requires, as resolved
+	Dependencies []Dependency `yaml:"dependencies,omitempty"`
+}
+
+// Dependency is a pack required by another pack
+type Dependency struct {
+	Pack    string `yaml:"pack"`              // [source/]pack as declared
+	Range   string `yaml:"range,omitempty"`   // Version range the dependent requires
+	Version string `yaml:"version,omitempty"` // Version of the installed pack
+	URL     string `yaml:"url"`               // Source the pack is installed as
+}
+
+// ShadowedFile is a file a source would render but that another source in a
+// higher scope renders instead
+type ShadowedFile struct {
+	Path       string `yaml:"path"`              // Output path both sources render
+	SourcePath string `yaml:"source"`            // Source path in this repository
+	By         string `yaml:"shadowed_by"`       // URL of the source that won
+	ByScope    string `yaml:"shadowed_by_scope"` // Scope of the source that won
+}
+
+// SkippedFile is a prompt an enabled adapter did not render because of the
+// prompt's targets
+type SkippedFile struct {
+	SourcePath string `yaml:"source"`  // Source path in this repository
+	Adapter    string `yaml:"adapter"` // Adapter that skipped the prompt
+	Reason     string `yaml:"reason"`
 }
 
 // File represents a file with its hash
@@ -27,6 +61,24 @@ type File struct {
 	Path       string `yaml:"path"`   // Output path (rendered file location)
 	SourcePath string `yaml:"source"` // Source path in the repository
 	Hash       string `yaml:"hash"`   // Hash of the rendered file
+
+	// Section marks files where prompt-sync only owns a managed section;
+	// Hash then covers just that section
+	Section bool `yaml:"section,omitempty"`
+
+	// Target is the targets entry that selected the adapter, omitted when
+	// the prompt declares no targets
+	Target string `yaml:"target,omitempty"`
+
+	// Includes lists the files included into the source file, which also
+	// contributed to the output; name:path for files of other sources
+	Includes []string `yaml:"includes,omitempty"`
+
+	// Security is the prompt's declared security level (omitted when none)
+	Security string `yaml:"security,omitempty"`
+	// ApprovedSecurity is the last approved level when Security increased
+	// without approval; omitted when Security itself is approved
+	ApprovedSecurity string `yaml:"approved_security,omitempty"`
 }
 
 // Lock represents the complete lock file structure
@@ -36,6 +88,16 @@ type Lock struct {
 	Sources   []Source  `yaml:"sources"`
 }
 
+// FormatVersion is the lock file format written by this release. Version 1.1
+// added per-file security levels.
+const FormatVersion = "1.1"
+
+// TracksSecurity reports whether the lock records security levels. Older lock
+// files leave them out, so a missing level there is unknown rather than none.
+func (l *Lock) TracksSecurity() bool {
+	return l != nil && l.Version != "" && l.Version != "1.0"
+}
+
 // Writer handles lock file generation and parsing
 type Writer struct {
 	workspaceDir string
@@ -63,7 +125,7 @@ func (w *Writer) Write(sources []Source) error {
 	}
 
 	lock := Lock{
-		Version:   "1.0",
+		Version:   FormatVersion,
 		Generated: time.Now().UTC(),
 		Sources:   sources,
 	}
@@ -78,14 +140,24 @@ func (w *Writer) Write(sources []Source) error {
 	header := "# Promptsfile.lock\n# Generated by prompt-sync\n# DO NOT EDIT MANUALLY\n\n"
 	content := header + string(data)
 
-	// Write to file
-	if err := os.WriteFile(lockPath, []byte(content), 0644); err != nil {
+	// Write to a temporary file and rename it so readers never see a partial lock
+	tmpPath := lockPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmpPath, lockPath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write lock file: %w", err)
 	}
 
 	return nil
 }
 
+// Path returns the location of the lock file
+func (w *Writer) Path() string {
+	return filepath.Join(w.workspaceDir, "Promptsfile.lock")
+}
+
 // Read parses an existing lock file
 func (w *Writer) Read() (*Lock, error) {
 	lockPath := filepath.Join(w.workspaceDir, "Promptsfile.lock")
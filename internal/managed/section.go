@@ -0,0 +1,103 @@
+// Package managed maintains the prompt-sync managed section of files that
+// also hold hand-written content, such as AGENTS.md.
+package managed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	beginMarker = "<!-- BEGIN PROMPT-SYNC MANAGED -->"
+	endMarker   = "<!-- END PROMPT-SYNC MANAGED -->"
+)
+
+// Merge replaces the managed section of content with section, or appends a
+// managed section when content has none. Everything outside the markers is
+// kept as is.
+func Merge(content, section []byte) []byte {
+	var block bytes.Buffer
+	block.WriteString(beginMarker + "\n")
+	block.Write(section)
+	if len(section) > 0 && !bytes.HasSuffix(section, []byte("\n")) {
+		block.WriteString("\n")
+	}
+	block.WriteString(endMarker + "\n")
+
+	start, _, _, end, ok := locate(content)
+	if !ok {
+		var buf bytes.Buffer
+		buf.Write(content)
+		if len(content) > 0 {
+			if !bytes.HasSuffix(content, []byte("\n")) {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("\n") // Blank line before the managed section
+		}
+		buf.Write(block.Bytes())
+		return buf.Bytes()
+	}
+
+	var buf bytes.Buffer
+	buf.Write(content[:start])
+	buf.Write(block.Bytes())
+	buf.Write(content[end:])
+	return buf.Bytes()
+}
+
+// Remove strips the managed section from content
+func Remove(content []byte) []byte {
+	start, _, _, end, ok := locate(content)
+	if !ok {
+		return content
+	}
+
+	before := bytes.TrimRight(content[:start], "\n")
+	after := bytes.TrimLeft(content[end:], "\n")
+
+	var buf bytes.Buffer
+	buf.Write(before)
+	if len(before) > 0 && len(after) > 0 {
+		buf.WriteString("\n\n")
+	} else if len(before) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.Write(after)
+	return buf.Bytes()
+}
+
+// Hash returns the hash of the managed section in content, in the format the
+// lock file uses. ok is false when content has no managed section.
+func Hash(content []byte) (hash string, ok bool) {
+	_, innerStart, innerEnd, _, ok := locate(content)
+	if !ok {
+		return "", false
+	}
+
+	sum := sha256.Sum256(content[innerStart:innerEnd])
+	return "sha256:" + hex.EncodeToString(sum[:]), true
+}
+
+// locate returns the byte range of the managed section, with the markers and
+// the newline after the end marker, and the range of the content between the
+// markers
+func locate(content []byte) (start, innerStart, innerEnd, end int, ok bool) {
+	start = bytes.Index(content, []byte(beginMarker+"\n"))
+	if start < 0 {
+		return 0, 0, 0, 0, false
+	}
+	innerStart = start + len(beginMarker) + 1
+
+	rel := bytes.Index(content[innerStart:], []byte(endMarker))
+	if rel < 0 {
+		return 0, 0, 0, 0, false
+	}
+	innerEnd = innerStart + rel
+
+	end = innerEnd + len(endMarker)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return start, innerStart, innerEnd, end, true
+}
@@ -0,0 +1,143 @@
+package pack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kovyrin/prompt-sync/internal/semver"
+)
+
+// ManifestFile is the file that marks a directory as a pack
+const ManifestFile = "pack.yaml"
+
+// Manifest describes a pack
+type Manifest struct {
+	Name         string   `yaml:"name"`
+	Version      string   `yaml:"version"`
+	Description  string   `yaml:"description"`
+	License      string   `yaml:"license"`
+	Maintainers  []string `yaml:"maintainers"`
+	Adapters     []string `yaml:"adapters"`     // Adapters the pack supports, all when empty
+	MinVersion   string   `yaml:"min_version"`  // Oldest prompt-sync release the pack works with
+	Dependencies []string `yaml:"dependencies"` // Other packs as [source/]pack[@range]
+}
+
+// Dependency is a pack another pack requires
+type Dependency struct {
+	Source string // Named source of the pack, "" for the source of the dependent
+	Pack   string
+	Range  string // Version range the pack's version must satisfy, "" for any
+}
+
+// Name returns the required pack as [source/]pack
+func (d Dependency) Name() string {
+	if d.Source == "" {
+		return d.Pack
+	}
+	return d.Source + "/" + d.Pack
+}
+
+// String returns the dependency as written in the manifest
+func (d Dependency) String() string {
+	if d.Range == "" {
+		return d.Name()
+	}
+	return d.Name() + "@" + d.Range
+}
+
+// LoadManifest reads the manifest of the pack in dir, or returns nil when
+// dir is not a pack
+func LoadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if manifest.Version != "" && !semver.IsVersion(manifest.Version) {
+		return nil, fmt.Errorf("%s: invalid version %q", path, manifest.Version)
+	}
+	if manifest.MinVersion != "" && !semver.IsVersion(manifest.MinVersion) {
+		return nil, fmt.Errorf("%s: invalid min_version %q", path, manifest.MinVersion)
+	}
+	if _, err := manifest.Requires(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// Requires parses the dependencies of the pack
+func (m *Manifest) Requires() ([]Dependency, error) {
+	deps := make([]Dependency, 0, len(m.Dependencies))
+	for _, spec := range m.Dependencies {
+		selection, versionRange, _ := strings.Cut(spec, "@")
+		source, name, named := strings.Cut(selection, "/")
+		if !named {
+			source, name = "", selection
+		}
+		if name == "" || (named && source == "") {
+			return nil, fmt.Errorf("invalid dependency %q: must be pack, source/pack or either with @range", spec)
+		}
+		if versionRange != "" {
+			if _, err := semver.ParseConstraint(versionRange); err != nil {
+				return nil, fmt.Errorf("invalid dependency %q: %w", spec, err)
+			}
+		}
+		deps = append(deps, Dependency{Source: source, Pack: name, Range: versionRange})
+	}
+	return deps, nil
+}
+
+// Supports reports whether the pack supports the named adapter
+func (m *Manifest) Supports(adapter string) bool {
+	if len(m.Adapters) == 0 {
+		return true
+	}
+	for _, supported := range m.Adapters {
+		if supported == adapter {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckToolVersion returns an error when the pack needs a newer prompt-sync
+// release than current
+func (m *Manifest) CheckToolVersion(current string) error {
+	if m.MinVersion == "" {
+		return nil
+	}
+	required, err := semver.Parse(m.MinVersion)
+	if err != nil {
+		return err
+	}
+	if v, err := semver.Parse(current); err != nil || v.Compare(required) < 0 {
+		return fmt.Errorf("pack %s requires prompt-sync %s or newer (this is %s)", m.Name, m.MinVersion, current)
+	}
+	return nil
+}
+
+// Satisfies reports whether the pack's version is within versionRange. A
+// pack without a version satisfies no range.
+func (m *Manifest) Satisfies(versionRange string) bool {
+	if versionRange == "" {
+		return true
+	}
+	constraint, err := semver.ParseConstraint(versionRange)
+	if err != nil {
+		return false
+	}
+	v, err := semver.Parse(m.Version)
+	return err == nil && constraint.Check(v)
+}
@@ -0,0 +1,107 @@
+// Package pack discovers the prompt packs of a source repository and reads
+// their manifests.
+//
+// A repository may hold several packs, each in a directory with a pack.yaml
+// manifest:
+//
+//	name: ruby-style        # defaults to the directory name
+//	version: 1.2.0
+//	description: Ruby conventions
+//	license: MIT
+//	maintainers: [Jane Doe <jane@example.com>]
+//	adapters: [cursor, claude]  # adapters the pack supports, all when empty
+//	min_version: 0.2.0      # oldest prompt-sync release the pack works with
+//	dependencies:           # [source/]pack[@range], the same source without source/
+//	  - git-workflow@^1.0
+//	  - shared/base@>=2.1
+//
+// The prompts of a pack live in its prompts/, rules/ or commands/ directory.
+package pack
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Pack is a pack found in a repository
+type Pack struct {
+	Manifest
+	Dir string // Directory relative to the repository root, slash-separated
+}
+
+// Discover returns the packs under root, sorted by name. Directories
+// starting with a dot are skipped and packs are not looked for inside packs.
+func Discover(root string) ([]Pack, error) {
+	var packs []Pack
+	byName := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		manifest, err := LoadManifest(path)
+		if err != nil || manifest == nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.ToSlash(rel)
+		if manifest.Name == "" {
+			manifest.Name = filepath.Base(path)
+		}
+		if other, ok := byName[manifest.Name]; ok {
+			return fmt.Errorf("pack %q is declared in both %s and %s", manifest.Name, other, dir)
+		}
+		byName[manifest.Name] = dir
+		packs = append(packs, Pack{Manifest: *manifest, Dir: dir})
+		if path == root {
+			return nil
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(packs, func(a, b int) bool { return packs[a].Name < packs[b].Name })
+	return packs, nil
+}
+
+// Resolve returns the directory, relative to root, of the pack selected by
+// name: the pack whose manifest declares that name or, failing that, the
+// directory of that path
+func Resolve(root, name string) (string, error) {
+	packs, err := Discover(root)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range packs {
+		if p.Name == name {
+			return p.Dir, nil
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err == nil && info.IsDir() {
+		return name, nil
+	}
+	if len(packs) == 0 {
+		return "", fmt.Errorf("pack %q not found", name)
+	}
+	names := make([]string, len(packs))
+	for idx, p := range packs {
+		names[idx] = p.Name
+	}
+	return "", fmt.Errorf("pack %q not found (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,194 @@
+// Package policy loads and enforces the organisation policy file.
+//
+// A policy file is YAML:
+//
+//	allowed_sources:        # hosts or namespaces sources must come from
+//	  - github.com:acme/*
+//	require_pinning:        # sources that must use a version tag or commit
+//	  - github.com:acme/prod-*
+//	max_security_level:     # highest prompt security level per scope
+//	  project: medium
+//	  personal: low
+//	forbidden_adapters: [claude]
+//	allow_unknown: false    # whether --allow-unknown may be used
+//	min_version: 0.2.0      # oldest prompt-sync release allowed to install
+package policy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/conflict"
+	"github.com/kovyrin/prompt-sync/internal/git"
+	"github.com/kovyrin/prompt-sync/internal/security"
+	"github.com/kovyrin/prompt-sync/internal/semver"
+)
+
+// EnvVar overrides the policy file configured in the user config
+const EnvVar = "PROMPT_SYNC_POLICY"
+
+// Policy holds the rules an install must satisfy
+type Policy struct {
+	AllowedSources    []string          `yaml:"allowed_sources"`
+	RequirePinning    []string          `yaml:"require_pinning"`
+	MaxSecurityLevel  map[string]string `yaml:"max_security_level"`
+	ForbiddenAdapters []string          `yaml:"forbidden_adapters"`
+	AllowUnknown      *bool             `yaml:"allow_unknown"`
+	MinVersion        string            `yaml:"min_version"`
+
+	// Path is the file the policy was loaded from
+	Path string `yaml:"-"`
+
+	maxLevels  map[string]security.Level
+	minVersion *semver.Version
+}
+
+// Install describes what an install is about to do
+type Install struct {
+	Sources      []Source
+	Files        []File
+	Adapters     []string // Enabled adapters
+	AllowUnknown bool
+	ToolVersion  string
+}
+
+// Source is a source selected for installation
+type Source struct {
+	URL   string
+	Ref   string
+	Scope string
+}
+
+// File is a rendered prompt file
+type File struct {
+	Path          string
+	SourceURL     string
+	Scope         string
+	SecurityLevel security.Level
+}
+
+// Path returns the policy file to enforce: $PROMPT_SYNC_POLICY, or the policy
+// named in the user config. It is empty when no policy applies.
+func Path(cfg *config.Config) string {
+	if p := os.Getenv(EnvVar); p != "" {
+		return p
+	}
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Policy
+}
+
+// Load reads and validates a policy file. An empty path returns a nil policy,
+// which allows everything. A configured but missing file is an error.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	p := &Policy{Path: path}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+
+	p.maxLevels = make(map[string]security.Level)
+	for scope, name := range p.MaxSecurityLevel {
+		level, err := security.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: max_security_level.%s: %w", path, scope, err)
+		}
+		p.maxLevels[scope] = level
+	}
+
+	if p.MinVersion != "" {
+		v, err := semver.Parse(p.MinVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: min_version: %w", path, err)
+		}
+		p.minVersion = &v
+	}
+
+	return p, nil
+}
+
+// Check evaluates an install against the policy and returns one issue per
+// violation. Every violation is critical; callers decide whether to warn or fail.
+func (p *Policy) Check(in Install) []conflict.Issue {
+	if p == nil {
+		return nil
+	}
+
+	var issues []conflict.Issue
+	add := func(path, format string, args ...interface{}) {
+		issues = append(issues, conflict.Issue{
+			Type:       "policy",
+			Path:       path,
+			Details:    fmt.Sprintf(format, args...),
+			IsCritical: true,
+		})
+	}
+
+	if p.minVersion != nil {
+		current, err := semver.Parse(in.ToolVersion)
+		if err != nil || current.Compare(*p.minVersion) < 0 {
+			add("prompt-sync", "version %s is older than the required %s", in.ToolVersion, p.MinVersion)
+		}
+	}
+
+	if p.AllowUnknown != nil && !*p.AllowUnknown && in.AllowUnknown {
+		add("--allow-unknown", "installing unknown sources is not permitted")
+	}
+
+	for _, name := range in.Adapters {
+		for _, forbidden := range p.ForbiddenAdapters {
+			if name == forbidden {
+				add(name, "adapter %s is forbidden", name)
+			}
+		}
+	}
+
+	for _, source := range in.Sources {
+		if len(p.AllowedSources) > 0 && !matchesAny(source.URL, p.AllowedSources) {
+			add(source.URL, "source is outside the allowed hosts and namespaces")
+		}
+		if matchesAny(source.URL, p.RequirePinning) && !isPinned(source.Ref) {
+			add(source.URL, "source must be pinned to a version tag or commit (ref %q)", source.Ref)
+		}
+	}
+
+	for _, file := range in.Files {
+		limit, ok := p.maxLevels[file.Scope]
+		if ok && file.SecurityLevel > limit {
+			add(file.Path, "security level %s exceeds the %s allowed for %s sources (from %s)",
+				file.SecurityLevel, limit, file.Scope, file.SourceURL)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+func matchesAny(url string, patterns []string) bool {
+	// Packs in a directory of a repository follow the rules of the repository
+	repo, _ := config.SplitSubdirectory(url)
+	for _, pattern := range patterns {
+		if security.Matches(repo, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPinned reports whether a ref names a fixed version tag or commit
+func isPinned(ref string) bool {
+	return semver.IsVersion(ref) || git.IsCommitHash(ref)
+}
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the risk label a prompt declares with "security:" in its front-matter.
+type Level int
+
+const (
+	// LevelNone means the prompt does not declare a level
+	LevelNone Level = iota
+	// LevelLow is read-only or advisory content
+	LevelLow
+	// LevelMedium generates or modifies code and configuration
+	LevelMedium
+	// LevelHigh executes commands or has other side-effects
+	LevelHigh
+)
+
+var levelNames = map[Level]string{
+	LevelNone:   "none",
+	LevelLow:    "low",
+	LevelMedium: "medium",
+	LevelHigh:   "high",
+}
+
+// ParseLevel parses "low", "medium" or "high". An empty string or "none" is
+// LevelNone.
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return LevelNone, nil
+	}
+	for level, name := range levelNames {
+		if name == s {
+			return level, nil
+		}
+	}
+	return LevelNone, fmt.Errorf("invalid security level %q (want low, medium or high)", s)
+}
+
+// String returns the level name
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// LevelFromMetadata reads the "security" key of merged prompt metadata
+// (metadata.yaml defaults and overrides plus front-matter).
+func LevelFromMetadata(metadata map[string]interface{}) (Level, error) {
+	value, ok := metadata["security"]
+	if !ok || value == nil {
+		return LevelNone, nil
+	}
+	name, ok := value.(string)
+	if !ok {
+		return LevelNone, fmt.Errorf("invalid security level %v (want low, medium or high)", value)
+	}
+	return ParseLevel(name)
+}
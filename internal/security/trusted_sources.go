@@ -12,14 +12,8 @@ import (
 // trailing "*" wildcard (prefix match) so that organisations can approve all
 // repos under a namespace (e.g. "github.com:shopify/*").
 func EnsureTrusted(repoURL string, cfg *config.Config, allowUnknown bool) error {
-	if cfg == nil {
-		cfg = &config.Config{}
-	}
-	canon := canonical(repoURL)
-	for _, s := range cfg.Sources {
-		if matchRepo(canon, canonical(s.Repo)) {
-			return nil
-		}
+	if _, ok := NewTrustedSources(cfg).TrustedBy(repoURL); ok {
+		return nil
 	}
 	if allowUnknown {
 		return nil
@@ -27,6 +21,12 @@ func EnsureTrusted(repoURL string, cfg *config.Config, allowUnknown bool) error
 	return fmt.Errorf("untrusted source: %s", repoURL)
 }
 
+// Matches reports whether repoURL matches an allow-list pattern such as
+// "github.com:acme/prompts" or the namespace wildcard "github.com:acme/*".
+func Matches(repoURL, pattern string) bool {
+	return matchRepo(canonical(repoURL), canonical(pattern))
+}
+
 func matchRepo(repoURL, allowed string) bool {
 	if strings.HasSuffix(allowed, "*") {
 		prefix := strings.TrimSuffix(allowed, "*")
@@ -61,34 +61,80 @@ func canonical(u string) string {
 	return u
 }
 
-// TrustedSources manages the list of trusted Git sources
+// TrustedSources is the allow-list declared by the trusted_sources: blocks of
+// the layered configuration (user config, Promptsfile and Promptsfile.local)
+// and the sources: block of the user config.
 type TrustedSources struct {
-	sources []string
+	sources []config.Source
+}
+
+// NewTrustedSources creates an allow-list from loaded configuration
+func NewTrustedSources(cfg *config.Config) *TrustedSources {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return &TrustedSources{sources: cfg.Sources}
 }
 
-// NewTrustedSources creates a new TrustedSources instance
-func NewTrustedSources() *TrustedSources {
-	// Default trusted sources
-	return &TrustedSources{
-		sources: []string{
-			"github.com:acme/*",
-			"github.com:tools/*",
-			"github.com:org/*",
-			"github.com:project/*",
-			"github.com:personal/*",
-			"github.com:pack1/*",
-			"github.com:pack2/*",
-		},
+// LoadTrustedSources reads the layered configuration of a project and returns
+// its allow-list.
+func LoadTrustedSources(projectDir string) (*TrustedSources, error) {
+	cfg, err := config.Load(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted sources: %w", err)
 	}
+	return NewTrustedSources(cfg), nil
 }
 
 // IsTrusted checks if a repository URL is trusted
 func (ts *TrustedSources) IsTrusted(repoURL string) bool {
+	_, ok := ts.TrustedBy(repoURL)
+	return ok
+}
+
+// TrustedBy returns the configured source that allows repoURL. Its File field
+// tells which configuration file granted the trust. When several sources
+// match, an exact repo wins over a wildcard, then a higher-precedence file
+// over a lower one, then the longer wildcard prefix.
+func (ts *TrustedSources) TrustedBy(repoURL string) (config.Source, bool) {
 	canon := canonical(repoURL)
-	for _, allowed := range ts.sources {
-		if matchRepo(canon, allowed) {
-			return true
+	var best config.Source
+	found := false
+	for _, s := range ts.sources {
+		if s.Repo == "" || !matchRepo(canon, canonical(s.Repo)) {
+			continue
 		}
+		if !found || moreSpecific(s, best) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// moreSpecific reports whether the trusted source a should be preferred over
+// b when both match the same repository
+func moreSpecific(a, b config.Source) bool {
+	aExact, bExact := !strings.HasSuffix(a.Repo, "*"), !strings.HasSuffix(b.Repo, "*")
+	if aExact != bExact {
+		return aExact
+	}
+	if a.Precedence != b.Precedence {
+		return a.Precedence > b.Precedence
 	}
-	return false
+	return len(canonical(a.Repo)) > len(canonical(b.Repo))
+}
+
+// Named returns the trusted source declared with the given name
+func (ts *TrustedSources) Named(name string) (config.Source, bool) {
+	for _, s := range ts.sources {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return config.Source{}, false
+}
+
+// UntrustedError explains how to allow an untrusted source
+func UntrustedError(repoURL string) error {
+	return fmt.Errorf("untrusted source: %s (declare it under sources: in ~/.prompt-sync/config.yaml or under trusted_sources: in Promptsfile or Promptsfile.local, or use --allow-unknown)", repoURL)
 }
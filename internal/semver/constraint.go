@@ -0,0 +1,164 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a version range such as "^1.2", "~2.0.3" or ">=1.0 <2.0".
+// All terms of a constraint must match for a version to satisfy it.
+type Constraint struct {
+	terms    []term
+	original string
+}
+
+// term is a single comparison such as ">=1.0.0"
+type term struct {
+	op      string
+	version Version
+}
+
+// rangeOperators are the prefixes that turn a ref into a version range
+var rangeOperators = []string{"^", "~", ">=", "<=", ">", "<", "="}
+
+// IsConstraint reports whether ref is a version range rather than a literal
+// branch, tag or commit. Plain versions such as "v1.2.3" are literal tags.
+func IsConstraint(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	for _, op := range rangeOperators {
+		if strings.HasPrefix(ref, op) {
+			_, err := ParseConstraint(ref)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// ParseConstraint parses a space separated list of range terms. Supported
+// operators are ^ (compatible with), ~ (patch updates only), =, >, >=, < and <=.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{original: strings.TrimSpace(s)}
+
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		op := ""
+		for _, candidate := range rangeOperators {
+			if strings.HasPrefix(field, candidate) {
+				op = candidate
+				break
+			}
+		}
+
+		raw := strings.TrimPrefix(field, op)
+		// Allow a space between the operator and the version (">= 1.0")
+		if raw == "" && op != "" && i+1 < len(fields) {
+			i++
+			raw = fields[i]
+		}
+
+		terms, err := expandTerm(op, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.terms = append(c.terms, terms...)
+	}
+
+	if len(c.terms) == 0 {
+		return nil, fmt.Errorf("invalid version constraint %q: empty", s)
+	}
+
+	return c, nil
+}
+
+// expandTerm converts caret and tilde ranges into plain comparisons
+func expandTerm(op, raw string) ([]term, error) {
+	v, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	components := len(strings.Split(strings.SplitN(strings.TrimPrefix(raw, "v"), "-", 2)[0], "."))
+
+	switch op {
+	case "^":
+		// The left-most non-zero component may not change
+		upper := Version{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && v.Minor == 0 && components == 3:
+			upper = Version{Patch: v.Patch + 1}
+		case v.Major == 0 && components >= 2:
+			upper = Version{Minor: v.Minor + 1}
+		}
+		return []term{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "~":
+		// Patch updates only, or minor updates when only the major is given
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if components == 1 {
+			upper = Version{Major: v.Major + 1}
+		}
+		return []term{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "":
+		return []term{{op: "=", version: v}}, nil
+	default:
+		return []term{{op: op, version: v}}, nil
+	}
+}
+
+// Check reports whether v satisfies every term of the constraint.
+// Prerelease versions only match when the constraint mentions a prerelease
+// of the same major, minor and patch version.
+func (c *Constraint) Check(v Version) bool {
+	if v.Prerelease != "" && !c.allowsPrerelease(v) {
+		return false
+	}
+
+	for _, t := range c.terms {
+		cmp := v.Compare(t.version)
+		var ok bool
+		switch t.op {
+		case "=":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Constraint) allowsPrerelease(v Version) bool {
+	for _, t := range c.terms {
+		if t.version.Prerelease != "" && t.version.Major == v.Major &&
+			t.version.Minor == v.Minor && t.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// Best returns the highest version that satisfies the constraint.
+func (c *Constraint) Best(versions []Version) (Version, bool) {
+	var best Version
+	found := false
+	for _, v := range versions {
+		if c.Check(v) && (!found || v.Compare(best) > 0) {
+			best = v
+			found = true
+		}
+	}
+	return best, found
+}
+
+// String returns the constraint as it was written.
+func (c *Constraint) String() string {
+	return c.original
+}
@@ -0,0 +1,145 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version such as "v1.2.3" or "1.2.3-rc.1".
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Original   string // The string the version was parsed from (e.g. the tag name)
+}
+
+// Parse parses a semantic version. A leading "v" is optional and missing minor
+// or patch components default to zero, so "v1" and "1.2" are accepted.
+// Build metadata after "+" is ignored.
+func Parse(s string) (Version, error) {
+	v := Version{Original: s}
+
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(rest, "+"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "-"); i >= 0 {
+		v.Prerelease = rest[i+1:]
+		rest = rest[:i]
+		if v.Prerelease == "" {
+			return Version{}, fmt.Errorf("invalid version %q: empty prerelease", s)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// IsVersion reports whether s parses as a semantic version.
+func IsVersion(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower than, equal to or
+// greater than other. Prerelease versions sort before the release they precede
+// and are ordered by comparePrerelease.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, other.Prerelease)
+	}
+}
+
+// comparePrerelease orders prerelease strings as SemVer §11 does: dot
+// separated identifiers are compared in turn, numeric ones numerically and
+// below alphanumeric ones, which compare as strings. A shorter list of equal
+// identifiers sorts first, so "alpha" < "alpha.1" and "rc.2" < "rc.10".
+func comparePrerelease(a, b string) int {
+	left, right := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		l, lErr := strconv.Atoi(left[i])
+		r, rErr := strconv.Atoi(right[i])
+		switch {
+		case lErr == nil && rErr == nil:
+			if l != r {
+				if l < r {
+					return -1
+				}
+				return 1
+			}
+		case lErr == nil:
+			return -1
+		case rErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(left[i], right[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the version without the original prefix.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// ParseAll parses every string that looks like a version and returns the
+// versions sorted in ascending order. Other strings are skipped.
+func ParseAll(names []string) []Version {
+	var versions []Version
+	for _, name := range names {
+		if v, err := Parse(name); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+	return versions
+}
@@ -6,8 +6,12 @@ import (
 	"testing"
 
 	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/adapter/agents"
 	"github.com/kovyrin/prompt-sync/internal/adapter/claude"
+	"github.com/kovyrin/prompt-sync/internal/adapter/copilot"
 	"github.com/kovyrin/prompt-sync/internal/adapter/cursor"
+	"github.com/kovyrin/prompt-sync/internal/adapter/gemini"
+	"github.com/kovyrin/prompt-sync/internal/adapter/windsurf"
 )
 
 // TestAdapterContract defines the expected behavior for any AgentAdapter
@@ -21,8 +25,12 @@ func TestAdapterContract(t *testing.T) {
 
 	adapters := []adapter.AgentAdapter{
 		// We'll add actual adapters here as we implement them
-		cursor.NewAdapter(),
-		claude.NewAdapter("test"),
+		cursor.NewAdapter(adapter.NamingFlat),
+		claude.NewAdapter("test", adapter.NamingFlat),
+		copilot.NewAdapter(adapter.NamingFlat),
+		agents.NewAdapter(),
+		windsurf.NewAdapter(adapter.NamingFlat),
+		gemini.NewAdapter(adapter.NamingFlat),
 	}
 
 	for _, a := range adapters {
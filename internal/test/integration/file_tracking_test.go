@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/git"
 	"github.com/kovyrin/prompt-sync/internal/lock"
 	"github.com/kovyrin/prompt-sync/internal/workflow"
 	"github.com/stretchr/testify/assert"
@@ -44,7 +45,7 @@ func TestFileTrackingIntegration(t *testing.T) {
 
 		// Create Promptsfile
 		promptsfile := &config.ExtendedConfig{
-			Sources: []string{testRepo},
+			Sources: []config.SourceCfg{config.ParseSource(testRepo)},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
 				Claude: config.ClaudeCfg{
@@ -92,8 +93,8 @@ func TestFileTrackingIntegration(t *testing.T) {
 		// Build expected mappings
 		expectedMappings := map[string]string{
 			// Cursor adapter outputs
-			".cursor/rules/_active/auth.md":     "prompts/auth.md",
-			".cursor/rules/_active/security.md": "rules/security.md",
+			".cursor/rules/_active/auth.mdc":     "prompts/auth.md",
+			".cursor/rules/_active/security.mdc": "rules/security.md",
 			// Claude adapter outputs
 			".claude/commands/test-validate.md": "commands/validate.md",
 		}
@@ -127,7 +128,7 @@ func TestFileTrackingIntegration(t *testing.T) {
 
 		// Create Promptsfile
 		promptsfile := &config.ExtendedConfig{
-			Sources: []string{testRepo},
+			Sources: []config.SourceCfg{config.ParseSource(testRepo)},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
 			},
@@ -183,11 +184,11 @@ func TestFileTrackingIntegration(t *testing.T) {
 		assert.Equal(t, "prompts/renamed.md", files[0].SourcePath)
 
 		// Old file should be cleaned up
-		oldFile := filepath.Join(workspace, ".cursor/rules/_active/initial.md")
+		oldFile := filepath.Join(workspace, ".cursor/rules/_active/initial.mdc")
 		assert.NoFileExists(t, oldFile)
 
 		// New file should exist
-		newFile := filepath.Join(workspace, ".cursor/rules/_active/renamed.md")
+		newFile := filepath.Join(workspace, ".cursor/rules/_active/renamed.mdc")
 		assert.FileExists(t, newFile)
 	})
 }
@@ -212,6 +213,22 @@ func (m *mockGitFetcher) CloneOrUpdate(url, ref string) (string, string, error)
 	return localPath, commit, nil
 }
 
+func (m *mockGitFetcher) ResolveRemote(url, ref string) (*git.RemoteRef, error) {
+	return nil, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *mockGitFetcher) CommitsBehind(url, from, to string) (int, error) {
+	return 0, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *mockGitFetcher) CheckoutCommit(url, commit string) (string, error) {
+	localPath, ok := m.repos[url]
+	if !ok {
+		return "", fmt.Errorf("repository not found: %s", url)
+	}
+	return localPath, nil
+}
+
 func (m *mockGitFetcher) Clone(url, ref string) (string, error) {
 	localPath, ok := m.repos[url]
 	if !ok {
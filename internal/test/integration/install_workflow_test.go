@@ -5,8 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/kovyrin/prompt-sync/internal/lock"
 	"github.com/kovyrin/prompt-sync/internal/workflow"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -70,7 +72,6 @@ adapters:
 	})
 
 	t.Run("applies overlay precedence correctly", func(t *testing.T) {
-		t.Skip("Overlay precedence feature not yet implemented in workflow")
 		workspace := t.TempDir()
 
 		// Create three test repos with same file but different content
@@ -78,24 +79,19 @@ adapters:
 		projectRepo := createTestRepoWithFile(t, "project-repo", "rules/policy.md", "# Project Policy\n\nProject level rule.")
 		personalRepo := createTestRepoWithFile(t, "personal-repo", "rules/policy.md", "# Personal Policy\n\nPersonal level rule.")
 
-		// Create Promptsfile with overlapping packs
-		promptsfile := fmt.Sprintf(`sources:
-  - file://%s#master
-  - file://%s#master
-  - file://%s#master
-
-overlays:
+		// Create Promptsfile with the same file in every scope
+		promptsfile := fmt.Sprintf(`overlays:
+  - scope: personal
+    source: file://%s#master
   - scope: org
     source: file://%s#master
   - scope: project
     source: file://%s#master
-  - scope: personal
-    source: file://%s#master
 
 adapters:
   cursor:
     enabled: true
-`, orgRepo, projectRepo, personalRepo, orgRepo, projectRepo, personalRepo)
+`, personalRepo, orgRepo, projectRepo)
 
 		err := os.WriteFile(filepath.Join(workspace, "Promptsfile"), []byte(promptsfile), 0644)
 		require.NoError(t, err)
@@ -106,20 +102,35 @@ adapters:
 			AllowUnknown: true,
 		})
 		require.NoError(t, err)
+		require.NoError(t, installer.Execute())
 
-		err = installer.Execute()
-		// Note: The current implementation doesn't actually implement overlay precedence
-		// It will fail with a conflict error because the same file exists in multiple sources
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "conflict")
+		// Personal version wins (highest precedence)
+		content, err := os.ReadFile(filepath.Join(workspace, ".cursor/rules/_active/policy.mdc"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Personal level rule")
+		assert.NotContains(t, string(content), "Org level rule")
+		assert.NotContains(t, string(content), "Project level rule")
 
-		// TODO: When overlay precedence is implemented, update this test to verify:
-		// - Personal version wins (highest precedence)
-		// - Should contain "Personal Policy" and "Personal level rule"
-		// - Should NOT contain "Org level rule" or "Project level rule"
+		// The lock records the winning scope and what was shadowed
+		lockData, err := lock.New(workspace).Read()
+		require.NoError(t, err)
+		for _, source := range lockData.Sources {
+			switch source.URL {
+			case "file://" + personalRepo:
+				assert.Equal(t, "personal", source.Scope)
+				assert.Len(t, source.Files, 1)
+				assert.Empty(t, source.Shadowed)
+			case "file://" + orgRepo, "file://" + projectRepo:
+				assert.Empty(t, source.Files)
+				require.Len(t, source.Shadowed, 1)
+				assert.Equal(t, ".cursor/rules/_active/policy.mdc", source.Shadowed[0].Path)
+				assert.Equal(t, "file://"+personalRepo, source.Shadowed[0].By)
+				assert.Equal(t, "personal", source.Shadowed[0].ByScope)
+			}
+		}
 	})
 
-	t.Run("preserves frontmatter in MDC files", func(t *testing.T) {
+	t.Run("normalizes frontmatter in MDC files", func(t *testing.T) {
 		workspace := t.TempDir()
 
 		// Create test repo with MDC file containing frontmatter
@@ -128,11 +139,12 @@ adapters:
 
 		// Create MDC file with frontmatter
 		mdcContent := `---
-title: Test Rule with Frontmatter
+description: Test Rule with Frontmatter
+globs:
+  - "*.go"
+  - "*.md"
+alwaysApply: "false"
 priority: high
-tags:
-  - testing
-  - mdc
 custom_settings:
   enabled: true
   level: strict
@@ -140,7 +152,7 @@ custom_settings:
 
 # Test Rule
 
-This MDC file has frontmatter that should be preserved.
+This MDC file has frontmatter that should be normalized.
 
 ::alert{type="info"}
 MDC components should also work
@@ -191,22 +203,17 @@ adapters:
 		err = installer.Execute()
 		require.NoError(t, err)
 
-		// Verify the rendered file preserves frontmatter
+		// Verify the rendered file keeps the Cursor rule fields
 		renderedPath := filepath.Join(workspace, ".cursor/rules/_active/test-rule.mdc")
 		assert.FileExists(t, renderedPath)
 
 		renderedContent, err := os.ReadFile(renderedPath)
 		require.NoError(t, err)
 
-		// Should contain all the frontmatter
-		assert.Contains(t, string(renderedContent), "title: Test Rule with Frontmatter")
-		assert.Contains(t, string(renderedContent), "priority: high")
-		assert.Contains(t, string(renderedContent), "tags:")
-		assert.Contains(t, string(renderedContent), "- testing")
-		assert.Contains(t, string(renderedContent), "- mdc")
-		assert.Contains(t, string(renderedContent), "custom_settings:")
-		assert.Contains(t, string(renderedContent), "enabled: true")
-		assert.Contains(t, string(renderedContent), "level: strict")
+		// Rule fields are normalized, unknown keys are kept after them
+		assert.True(t, strings.HasPrefix(string(renderedContent),
+			"---\ndescription: Test Rule with Frontmatter\nglobs: *.go,*.md\nalwaysApply: false\n"+
+				"custom_settings:\n    enabled: true\n    level: strict\npriority: high\n---\n\n"))
 
 		// Should also contain the body content
 		assert.Contains(t, string(renderedContent), "# Test Rule")
@@ -335,7 +342,7 @@ adapters:
 		assert.FileExists(t, lockPath)
 
 		// Now modify the rendered file to create drift
-		renderedPath := filepath.Join(workspace, ".cursor/rules/_active/coding.md")
+		renderedPath := filepath.Join(workspace, ".cursor/rules/_active/coding.mdc")
 		modifiedContent := "# Modified Content\n\nThis has been changed!"
 		require.NoError(t, os.WriteFile(renderedPath, []byte(modifiedContent), 0644))
 
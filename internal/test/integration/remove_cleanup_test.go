@@ -32,10 +32,10 @@ func TestRemoveCommandCleansRenderedFiles(t *testing.T) {
 
 	// Verify multiple files exist
 	cursorFiles := []string{
-		filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"),
-		filepath.Join(workDir, ".cursor/rules/_active/event-driven.md"),
+		filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"),
+		filepath.Join(workDir, ".cursor/rules/_active/event-driven.mdc"),
 		filepath.Join(workDir, ".cursor/rules/_active/microservices.mdc"),
-		filepath.Join(workDir, ".cursor/rules/_active/unit-testing.md"),
+		filepath.Join(workDir, ".cursor/rules/_active/unit-testing.mdc"),
 	}
 
 	for _, file := range cursorFiles {
@@ -73,8 +73,8 @@ func TestRemoveCommandWithMultipleSources(t *testing.T) {
 	runCmd(t, workDir, binPath, "add", "file://"+repoPath2+"#master", "--allow-unknown")
 
 	// Check files from both sources exist
-	file1 := filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md")
-	file2 := filepath.Join(workDir, ".cursor/rules/_active/coding.md")
+	file1 := filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc")
+	file2 := filepath.Join(workDir, ".cursor/rules/_active/coding.mdc")
 	assert.FileExists(t, file1, "File from source 1")
 	assert.FileExists(t, file2, "File from source 2")
 
@@ -4,18 +4,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/git"
 	"github.com/kovyrin/prompt-sync/internal/lock"
 	"github.com/kovyrin/prompt-sync/internal/workflow"
 )
 
 // MockVersionedGitFetcher simulates different versions of a repository
 type MockVersionedGitFetcher struct {
+	mu          sync.Mutex // The installer fetches sources concurrently
 	clonedRepos map[string]string
 	versions    map[string]map[string][]string // url -> version -> files
 }
@@ -50,12 +54,28 @@ func (m *MockVersionedGitFetcher) Update(url, ref string) error {
 }
 
 func (m *MockVersionedGitFetcher) CachedPath(url, ref string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if path, exists := m.clonedRepos[url+"#"+ref]; exists {
 		return path, true
 	}
 	return "", false
 }
 
+func (m *MockVersionedGitFetcher) ResolveRemote(url, ref string) (*git.RemoteRef, error) {
+	return nil, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *MockVersionedGitFetcher) CommitsBehind(url, from, to string) (int, error) {
+	return 0, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *MockVersionedGitFetcher) CheckoutCommit(url, commit string) (string, error) {
+	// Mock commits encode the version they were created from
+	path, _, err := m.CloneOrUpdate(url, strings.TrimPrefix(commit, "abc123-"))
+	return path, err
+}
+
 func (m *MockVersionedGitFetcher) CloneOrUpdate(url, ref string) (string, string, error) {
 	// Create a temp directory for this "clone"
 	tmpDir, err := os.MkdirTemp("", "mock-repo-*")
@@ -92,11 +112,15 @@ func (m *MockVersionedGitFetcher) CloneOrUpdate(url, ref string) (string, string
 	// Mock commit hash
 	commitHash := "abc123-" + version
 
+	m.mu.Lock()
 	m.clonedRepos[url+"#"+ref] = tmpDir
+	m.mu.Unlock()
 	return tmpDir, commitHash, nil
 }
 
 func (m *MockVersionedGitFetcher) Cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, dir := range m.clonedRepos {
 		os.RemoveAll(dir)
 	}
@@ -108,7 +132,7 @@ func TestVersionSwitchingWithCleanup(t *testing.T) {
 
 	// Create initial Promptsfile with v1.0.0
 	cfg := &config.ExtendedConfig{
-		Sources: []string{"github.com/test/prompts#v1.0.0"},
+		Sources: []config.SourceCfg{config.ParseSource("github.com/test/prompts#v1.0.0")},
 		Adapters: config.AdaptersCfg{
 			Cursor: config.CursorCfg{Enabled: true},
 			Claude: config.ClaudeCfg{Enabled: false},
@@ -136,13 +160,13 @@ func TestVersionSwitchingWithCleanup(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify v1.0.0 files exist
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.md"))
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.md"))
-	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"))
-	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.md"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.mdc"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.mdc"))
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"))
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.mdc"))
 
 	// Update to v2.0.0
-	cfg.Sources = []string{"github.com/test/prompts#v2.0.0"}
+	cfg.Sources = []config.SourceCfg{config.ParseSource("github.com/test/prompts#v2.0.0")}
 	require.NoError(t, writeTestConfig(configPath, cfg))
 
 	// Second install with v2.0.0
@@ -158,10 +182,10 @@ func TestVersionSwitchingWithCleanup(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify v2.0.0 files exist and v1.0.0-only files are removed
-	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.md"), "Old file should be removed")
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"), "New file should exist")
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.md"), "Common file should remain")
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.md"), "New file should exist")
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.mdc"), "Old file should be removed")
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"), "New file should exist")
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.mdc"), "Common file should remain")
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.mdc"), "New file should exist")
 
 	// Verify lock file is updated correctly
 	lockWriter := lock.New(workDir)
@@ -181,9 +205,9 @@ func TestVersionSwitchingWithMultipleSources(t *testing.T) {
 
 	// Create initial Promptsfile with two sources
 	cfg := &config.ExtendedConfig{
-		Sources: []string{
-			"github.com/test/prompts#v1.0.0",
-			"github.com/other/prompts#v1.0.0",
+		Sources: []config.SourceCfg{
+			config.ParseSource("github.com/test/prompts#v1.0.0"),
+			config.ParseSource("github.com/other/prompts#v1.0.0"),
 		},
 		Adapters: config.AdaptersCfg{
 			Cursor: config.CursorCfg{Enabled: true},
@@ -220,13 +244,13 @@ func TestVersionSwitchingWithMultipleSources(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify initial files
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-old.md"))
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/other.md"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-old.mdc"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/other.mdc"))
 
 	// Update only the first source
-	cfg.Sources = []string{
-		"github.com/test/prompts#v2.0.0",
-		"github.com/other/prompts#v1.0.0",
+	cfg.Sources = []config.SourceCfg{
+		config.ParseSource("github.com/test/prompts#v2.0.0"),
+		config.ParseSource("github.com/other/prompts#v1.0.0"),
 	}
 	require.NoError(t, writeTestConfig(configPath, cfg))
 
@@ -242,9 +266,9 @@ func TestVersionSwitchingWithMultipleSources(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify only test source files were updated
-	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-old.md"), "Old test file should be removed")
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-new.md"), "New test file should exist")
-	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/other.md"), "Other source file should remain")
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-old.mdc"), "Old test file should be removed")
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/test-new.mdc"), "New test file should exist")
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/other.mdc"), "Other source file should remain")
 }
 
 // Helper function to write test config
@@ -252,7 +276,7 @@ func writeTestConfig(path string, cfg *config.ExtendedConfig) error {
 	// Simple YAML writer for test
 	content := "sources:\n"
 	for _, s := range cfg.Sources {
-		content += "  - " + s + "\n"
+		content += "  - " + s.String() + "\n"
 	}
 	content += "adapters:\n"
 	content += "  cursor:\n"
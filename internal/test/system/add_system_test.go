@@ -11,6 +11,8 @@ import (
 )
 
 func TestAddCommand(t *testing.T) {
+	trustTestNamespaces(t)
+
 	// Build binary once for all tests
 	tempDir := t.TempDir()
 	binaryPath := filepath.Join(tempDir, "prompt-sync-test-bin")
@@ -250,6 +252,8 @@ func TestAddCommandHelp(t *testing.T) {
 }
 
 func TestAddCommandInCIMode(t *testing.T) {
+	trustTestNamespaces(t)
+
 	// Build binary
 	tempDir := t.TempDir()
 	binaryPath := filepath.Join(tempDir, "prompt-sync-test-bin")
@@ -280,3 +284,18 @@ func TestAddCommandInCIMode(t *testing.T) {
 	require.NoError(t, err, "should allow trusted source in CI mode: %s", string(output))
 	assert.Contains(t, string(output), "✓ Added source")
 }
+
+// trustTestNamespaces points the binary at a user config that trusts every
+// repo under github.com/org and github.com/tools
+func trustTestNamespaces(t *testing.T) {
+	t.Helper()
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte(`sources:
+  - name: org
+    repo: github.com:org/*
+  - name: tools
+    repo: github.com:tools/*
+`), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+}
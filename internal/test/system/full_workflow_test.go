@@ -67,8 +67,8 @@ func TestFullWorkflow(t *testing.T) {
 			// Verify Promptsfile was updated
 			cfg := readWorkflowPromptsfile(t, workDir)
 			assert.Len(t, cfg.Sources, 2)
-			assert.Contains(t, cfg.Sources, "file://"+acmeRepo+"#v1.0.0")
-			assert.Contains(t, cfg.Sources, "file://"+devRepo+"#master")
+			assert.Contains(t, cfg.Sources, config.ParseSource("file://"+acmeRepo+"#v1.0.0"))
+			assert.Contains(t, cfg.Sources, config.ParseSource("file://"+devRepo+"#master"))
 		})
 
 		// Step 3: Install prompts and verify rendering
@@ -94,8 +94,8 @@ func TestFullWorkflow(t *testing.T) {
 
 			// Verify files were rendered for both adapters
 			// Cursor files
-			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.md"))
-			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/testing.md"))
+			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.mdc"))
+			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/testing.mdc"))
 
 			// Claude files (with prefix)
 			claudeFiles, err := filepath.Glob(filepath.Join(workDir, ".claude/commands/workflow-*.md"))
@@ -126,8 +126,8 @@ func TestFullWorkflow(t *testing.T) {
 			cmd.Dir = workDir
 			output, err = cmd.CombinedOutput()
 			require.NoError(t, err, "List --files failed: %s", string(output))
-			assert.Contains(t, string(output), ".cursor/rules/_active/coding.md")
-			assert.Contains(t, string(output), ".cursor/rules/_active/testing.md")
+			assert.Contains(t, string(output), ".cursor/rules/_active/coding.mdc")
+			assert.Contains(t, string(output), ".cursor/rules/_active/testing.mdc")
 
 			// List with JSON output
 			cmd = exec.Command(binPath, "list", "--json")
@@ -176,13 +176,13 @@ func TestFullWorkflow(t *testing.T) {
 			// Verify it was removed from Promptsfile
 			cfg := readWorkflowPromptsfile(t, workDir)
 			assert.Len(t, cfg.Sources, 1)
-			assert.NotContains(t, cfg.Sources, devRepo)
+			assert.NotContains(t, cfg.Sources, config.ParseSource(devRepo))
 
 			// Verify rendered files were cleaned up
-			assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/testing.md"))
+			assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/testing.mdc"))
 
 			// But coding.md should still exist (from acme-prompts)
-			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.md"))
+			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.mdc"))
 		})
 
 		// Step 7: Verify consistency
@@ -195,7 +195,7 @@ func TestFullWorkflow(t *testing.T) {
 			assert.Contains(t, string(output), "✓ All files verified successfully")
 
 			// Modify a rendered file to create drift
-			codingPath := filepath.Join(workDir, ".cursor/rules/_active/coding.md")
+			codingPath := filepath.Join(workDir, ".cursor/rules/_active/coding.mdc")
 			err = os.WriteFile(codingPath, []byte("Modified content"), 0644)
 			require.NoError(t, err)
 
@@ -231,8 +231,8 @@ func TestFullWorkflow(t *testing.T) {
 
 		// Manually update Promptsfile to enable both adapters with custom prefix
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#master",
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#master"),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -251,7 +251,7 @@ func TestFullWorkflow(t *testing.T) {
 		require.NoError(t, err, "Install failed: %s", string(output))
 
 		// Verify both adapters rendered files
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.mdc"))
 
 		// Check Claude file with prefix
 		claudeFiles, err := filepath.Glob(filepath.Join(workDir, ".claude/commands/test-*.md"))
@@ -370,7 +370,7 @@ func TestFullWorkflow(t *testing.T) {
 
 		// Now change back to v1.0.0 by editing Promptsfile (simulate downgrade)
 		cfg := readWorkflowPromptsfile(t, workDir)
-		cfg.Sources[0] = "file://" + acmeRepo + "#v1.0.0"
+		cfg.Sources[0] = config.ParseSource("file://" + acmeRepo + "#v1.0.0")
 		writeWorkflowPromptsfile(t, workDir, cfg)
 
 		// Reinstall to apply downgrade
@@ -422,7 +422,7 @@ func TestFullWorkflow(t *testing.T) {
 		require.NoError(t, err, "Offline install failed: %s", string(output))
 
 		// Verify files were rendered from cache
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/coding.mdc"))
 	})
 
 	t.Run("interrupted operation recovery", func(t *testing.T) {
@@ -479,10 +479,10 @@ func TestFullWorkflow(t *testing.T) {
 		extraRepo := createTestRepoWithConflict(t, "extra-repo", "rules/extra.md", "# Extra Rules")
 
 		// Add mix of pinned and unpinned sources
-		sources := []string{
-			"file://" + acmeRepo + "#v1.0.0",  // Pinned to tag
-			"file://" + devRepo + "#master",   // Pinned to branch (considered unpinned for updates)
-			"file://" + extraRepo + "#master", // Another source at master
+		sources := []config.SourceCfg{
+			config.ParseSource("file://" + acmeRepo + "#v1.0.0"),  // Pinned to tag
+			config.ParseSource("file://" + devRepo + "#master"),   // Pinned to branch (considered unpinned for updates)
+			config.ParseSource("file://" + extraRepo + "#master"), // Another source at master
 		}
 
 		// Update Promptsfile directly to avoid conflicts
@@ -78,13 +78,13 @@ func TestRealWorldScenarios(t *testing.T) {
 		require.NoError(t, err, "Add v1.0.0 failed: %s", string(output))
 
 		// Verify v1.0.0 content
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.md"))
-		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"))
-		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.mdc"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.mdc"))
 
 		// Update to v2.0.0 with breaking changes
 		cfg := readRealWorldPromptsfile(t, workDir)
-		cfg.Sources[0] = "file://" + enterpriseRepo + "#v2.0.0"
+		cfg.Sources[0] = config.ParseSource("file://" + enterpriseRepo + "#v2.0.0")
 		writeRealWorldPromptsfile(t, workDir, cfg)
 
 		cmd = exec.Command(binPath, "install", "--yes", "--allow-unknown")
@@ -93,18 +93,18 @@ func TestRealWorldScenarios(t *testing.T) {
 		require.NoError(t, err, "Install v2.0.0 failed: %s", string(output))
 
 		// With the version switching cleanup fix, old files should now be removed
-		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.md"), "authentication.md should be removed when switching to v2.0.0")
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"), "auth-patterns.md should exist in v2.0.0")
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.md"), "breaking-changes.md should exist in v2.0.0")
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.mdc"), "authentication.md should be removed when switching to v2.0.0")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"), "auth-patterns.md should exist in v2.0.0")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.mdc"), "breaking-changes.md should exist in v2.0.0")
 
 		// Test that common files (present in both versions) are preserved
 		// The test data shows that enterprise-prompts has different files between versions
 		// v1.0.0: security/authentication.md, testing/unit-testing.md
 		// v2.0.0: security/auth-patterns.md, testing/unit-testing.md, breaking-changes.md, architecture/event-driven.md, architecture/microservices.mdc
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/unit-testing.md"), "unit-testing.md should remain (exists in both versions)")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/unit-testing.mdc"), "unit-testing.md should remain (exists in both versions)")
 
 		// Also verify new v2.0.0 files exist
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/event-driven.md"), "event-driven.md should exist in v2.0.0")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/event-driven.mdc"), "event-driven.md should exist in v2.0.0")
 		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/microservices.mdc"), "microservices.mdc should exist in v2.0.0")
 
 		// Verify the remove/re-add workflow is no longer necessary
@@ -122,7 +122,7 @@ func TestRealWorldScenarios(t *testing.T) {
 
 		// Update config to enable both adapters
 		cfg := &config.ExtendedConfig{
-			Sources: []string{},
+			Sources: []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
 				Claude: config.ClaudeCfg{
@@ -267,13 +267,13 @@ func TestRealWorldScenarios(t *testing.T) {
 		}
 
 		// Verify master content - should not have AI guidelines
-		promptingPath := filepath.Join(workDir, ".cursor/rules/_active/prompting.md")
+		promptingPath := filepath.Join(workDir, ".cursor/rules/_active/prompting.mdc")
 		_, err = os.Stat(promptingPath)
 		assert.True(t, os.IsNotExist(err), "AI prompting guide should not exist on master branch")
 
 		// Switch to develop branch
 		cfg := readRealWorldPromptsfile(t, workDir)
-		cfg.Sources[0] = "file://" + teamRepo + "#develop"
+		cfg.Sources[0] = config.ParseSource("file://" + teamRepo + "#develop")
 		writeRealWorldPromptsfile(t, workDir, cfg)
 
 		cmd = exec.Command(binPath, "install", "--yes", "--allow-unknown")
@@ -282,9 +282,9 @@ func TestRealWorldScenarios(t *testing.T) {
 		require.NoError(t, err, "Install develop branch failed: %s", string(output))
 
 		// Verify develop content is now present
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/prompting.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/prompting.mdc"))
 
-		content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/prompting.md"))
+		content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/prompting.mdc"))
 		require.NoError(t, err)
 		assert.Contains(t, string(content), "AI Prompting Guidelines")
 	})
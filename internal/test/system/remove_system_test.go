@@ -11,6 +11,8 @@ import (
 )
 
 func TestRemoveCommand(t *testing.T) {
+	trustTestNamespaces(t)
+
 	// Build binary once for all tests
 	tempDir := t.TempDir()
 	binaryPath := filepath.Join(tempDir, "prompt-sync-test-bin")
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,19 +19,19 @@ func TestUpdateSystemCommand(t *testing.T) {
 	// Build the binary
 	binPath := buildPromptSyncBinary(t)
 
-	// Get project root for accessing test repos
-	projectRoot := getProjectRootForSystemTest(t)
-	acmeRepo := filepath.Join(projectRoot, "testdata/repos/acme-prompts")
-	devRepo := filepath.Join(projectRoot, "testdata/repos/dev-prompts")
+	// Create real repositories so update can resolve their refs
+	acmeRepo := createSystemTestRepo(t, map[string]string{"prompts/coding.md": "# Coding\n"})
+	runSystemTestGit(t, acmeRepo, "tag", "v1.0.0")
+	devRepo := createSystemTestRepo(t, map[string]string{"prompts/testing.md": "# Testing\n"})
 
 	t.Run("update all unpinned sources", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
 		// Create Promptsfile with test sources
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,            // Unpinned
-				"file://" + devRepo + "#master", // Pinned to branch (not considered pinned for updates)
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),            // Unpinned
+				config.ParseSource("file://" + devRepo + "#master"), // Pinned to branch (not considered pinned for updates)
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -64,9 +65,9 @@ func TestUpdateSystemCommand(t *testing.T) {
 
 		// Create Promptsfile
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
-				"file://" + devRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
+				config.ParseSource("file://" + devRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -98,8 +99,8 @@ func TestUpdateSystemCommand(t *testing.T) {
 
 		// Create Promptsfile with pinned source
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#v1.0.0", // Pinned
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#v1.0.0"), // Pinned
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -144,8 +145,8 @@ func TestUpdateSystemCommand(t *testing.T) {
 
 		// Create only Promptsfile, no lock file
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -164,8 +165,8 @@ func TestUpdateSystemCommand(t *testing.T) {
 
 		// Create Promptsfile
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -195,8 +196,8 @@ func TestUpdateSystemCommand(t *testing.T) {
 
 		// Create Promptsfile
 		cfg := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#master",
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#master"),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -234,6 +235,35 @@ func TestUpdateSystemCommand(t *testing.T) {
 }
 
 // Helper functions
+
+// createSystemTestRepo creates a git repository on the master branch with
+// the given files committed
+func createSystemTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	repoDir := t.TempDir()
+	runSystemTestGit(t, repoDir, "init")
+	runSystemTestGit(t, repoDir, "checkout", "-b", "master")
+	runSystemTestGit(t, repoDir, "config", "user.email", "test@example.com")
+	runSystemTestGit(t, repoDir, "config", "user.name", "Test User")
+	for name, content := range files {
+		path := filepath.Join(repoDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	runSystemTestGit(t, repoDir, "add", "-A")
+	runSystemTestGit(t, repoDir, "commit", "-m", "Initial commit")
+
+	return repoDir
+}
+
+func runSystemTestGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), output)
+}
 func writeSystemTestPromptsfile(t *testing.T, dir string, cfg *config.ExtendedConfig) {
 	t.Helper()
 	data, err := yaml.Marshal(cfg)
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+// installPlugin puts a prompt-sync-adapter-<name> script on PATH that records
+// each request in requestDir and answers with a canned response
+func installPlugin(t *testing.T, name, requestDir string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+input=$(cat)
+case "$input" in
+  *'"command":"describe"'*) command=describe; response='{"target_dir":".acme/rules"}' ;;
+  *'"command":"discover"'*) command=discover; response='{"files":[{"source_path":"prompts/style.md"}]}' ;;
+  *'"command":"render"'*) command=render; response='{"files":[{"path":"style.rule","source_path":"prompts/style.md","content":"rendered by plugin\n"}]}' ;;
+  *'"command":"gitignore"'*) command=gitignore; response='{"patterns":[".acme/rules/*.rule"]}' ;;
+  *) echo "unknown request" >&2; exit 1 ;;
+esac
+printf '%s' "$input" > "` + requestDir + `/$command.json"
+printf '%s' "$response"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "prompt-sync-adapter-"+name), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestInstall_AdapterPlugin(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style\n"})
+	requestDir := t.TempDir()
+	installPlugin(t, "acme", requestDir)
+
+	writePromptsfile := func(t *testing.T, adapters string) string {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nadapters:\n"+adapters), 0644))
+		return workDir
+	}
+
+	t.Run("plugin renders files tracked like built-in adapters", func(t *testing.T) {
+		workDir := writePromptsfile(t, "  acme:\n    enabled: true\n    model: fast\n")
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+		content, err := os.ReadFile(filepath.Join(workDir, ".acme/rules/style.rule"))
+		require.NoError(t, err)
+		assert.Equal(t, "rendered by plugin\n", string(content))
+		assert.NoDirExists(t, filepath.Join(workDir, ".cursor"), "a configured plugin replaces the default adapter")
+
+		assert.Equal(t, ".acme/rules/style.rule", lockedFile(t, workDir, "prompts/style.md").Path)
+
+		gitignore, err := os.ReadFile(filepath.Join(workDir, ".gitignore"))
+		require.NoError(t, err)
+		assert.Contains(t, string(gitignore), ".acme/rules/*.rule")
+
+		request, err := os.ReadFile(filepath.Join(requestDir, "render.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(request), `"config":{"model":"fast"}`, "the adapters block is passed through")
+		assert.Contains(t, string(request), `"content":"# Style\n"`)
+	})
+
+	t.Run("plugin is sent prompts with includes and templates applied", func(t *testing.T) {
+		repo := createPromptRepo(t, map[string]string{
+			"prompts/style.md":  "---\ndescription: Style\ntemplate: true\n---\n# Style for {{.team}} in {{adapter}}\n{{> partials/tone.md}}\n",
+			"partials/tone.md":  "Be kind.\n",
+			"prompts/README.md": "Not discovered.\n",
+		})
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nvars:\n  team: platform\nadapters:\n  acme:\n    enabled: true\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+		request, err := os.ReadFile(filepath.Join(requestDir, "render.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(request), `"content":"---\ndescription: Style\ntemplate: true\n---\n# Style for platform in acme\nBe kind.\n"`)
+	})
+
+	t.Run("missing plugin is an error", func(t *testing.T) {
+		workDir := writePromptsfile(t, "  windsurf-beta:\n    enabled: true\n")
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{})
+		assert.ErrorContains(t, err, `unknown adapter "windsurf-beta"`)
+	})
+
+	t.Run("disabled plugin blocks are ignored", func(t *testing.T) {
+		workDir := writePromptsfile(t, "  missing:\n    enabled: false\n")
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+	})
+}
@@ -14,7 +14,19 @@ import (
 	"github.com/kovyrin/prompt-sync/internal/config"
 )
 
+// trustNamespace writes a user config that trusts every repo under github.com/org
+func trustNamespace(t *testing.T) string {
+	t.Helper()
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("sources:\n  - name: org\n    repo: github.com:org/*\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+	return userConfig
+}
+
 func TestAddCommand(t *testing.T) {
+	trustNamespace(t)
+
 	t.Run("adding valid prompt sources", func(t *testing.T) {
 		// Create temp directory
 		tmpDir := t.TempDir()
@@ -24,8 +36,8 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/existing-prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/existing-prompts"),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -41,8 +53,8 @@ func TestAddCommand(t *testing.T) {
 
 		// Verify the source was added
 		cfg := readPromptsfile(t, tmpDir)
-		assert.Contains(t, cfg.Sources, "github.com/org/existing-prompts")
-		assert.Contains(t, cfg.Sources, "github.com/org/new-prompts")
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/existing-prompts"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/new-prompts"))
 		assert.Len(t, cfg.Sources, 2)
 	})
 
@@ -54,7 +66,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources:  []string{},
+			Sources:  []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
 		writePromptsfile(t, tmpDir, initialConfig)
@@ -88,7 +100,7 @@ func TestAddCommand(t *testing.T) {
 			assert.NoError(t, err)
 
 			cfg := readPromptsfile(t, tmpDir)
-			assert.Contains(t, cfg.Sources, tc.expected)
+			assert.Contains(t, cfg.Sources, config.ParseSource(tc.expected))
 		}
 	})
 
@@ -100,7 +112,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources:  []string{},
+			Sources:  []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
 		writePromptsfile(t, tmpDir, initialConfig)
@@ -123,7 +135,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources:  []string{},
+			Sources:  []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
 		writePromptsfile(t, tmpDir, initialConfig)
@@ -137,7 +149,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Verify source was added
 		cfg := readPromptsfile(t, tmpDir)
-		assert.Contains(t, cfg.Sources, "github.com/untrusted/prompts")
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/untrusted/prompts"))
 	})
 
 	t.Run("handling duplicate prompt names", func(t *testing.T) {
@@ -148,8 +160,8 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile with a source
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -163,7 +175,7 @@ func TestAddCommand(t *testing.T) {
 		// Verify no duplicate was added
 		cfg := readPromptsfile(t, tmpDir)
 		assert.Len(t, cfg.Sources, 1)
-		assert.Equal(t, "github.com/org/prompts", cfg.Sources[0])
+		assert.Equal(t, "github.com/org/prompts", cfg.Sources[0].String())
 	})
 
 	t.Run("adding source with --no-install flag", func(t *testing.T) {
@@ -174,7 +186,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources:  []string{},
+			Sources:  []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
 		writePromptsfile(t, tmpDir, initialConfig)
@@ -187,7 +199,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Verify source was added
 		cfg := readPromptsfile(t, tmpDir)
-		assert.Contains(t, cfg.Sources, "github.com/org/prompts")
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/prompts"))
 
 		// Verify no lock file was created (install was not triggered)
 		_, err = os.Stat(filepath.Join(tmpDir, "Promptsfile.lock"))
@@ -202,7 +214,7 @@ func TestAddCommand(t *testing.T) {
 
 		// Create initial Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources:  []string{},
+			Sources:  []config.SourceCfg{},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
 		writePromptsfile(t, tmpDir, initialConfig)
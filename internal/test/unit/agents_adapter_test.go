@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/managed"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestManagedSection(t *testing.T) {
+	content := managed.Merge([]byte("# Notes\n"), []byte("generated\n"))
+	assert.Equal(t, "# Notes\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\ngenerated\n<!-- END PROMPT-SYNC MANAGED -->\n", string(content))
+
+	updated := managed.Merge(append(content, "\nMore notes\n"...), []byte("regenerated\n"))
+	assert.Equal(t, "# Notes\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\nregenerated\n<!-- END PROMPT-SYNC MANAGED -->\n\nMore notes\n", string(updated))
+
+	hash, ok := managed.Hash(updated)
+	require.True(t, ok)
+	edited, _ := managed.Hash([]byte(strings.Replace(string(updated), "More notes", "Other notes", 1)))
+	assert.Equal(t, hash, edited, "only the managed section is hashed")
+
+	assert.Equal(t, "# Notes\n\nMore notes\n", string(managed.Remove(updated)))
+}
+
+func TestInstall_AgentsAdapter(t *testing.T) {
+	orgRepo := createPromptRepo(t, map[string]string{"prompts/tone.md": "# Org tone\n"})
+	projectRepo := createPromptRepo(t, map[string]string{
+		"prompts/style.md":   "# Project style\n",
+		"prompts/api.md":     "---\npaths: [services/api]\n---\n# API rules\n",
+		"prompts/release.md": "---\nkind: command\n---\nTag and publish.\n",
+	})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Hand-written notes\n"), 0644))
+	writePromptsfile := func(adapters string) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+			"sources:\n  - "+projectRepo+"#main\n"+
+				"overlays:\n  - scope: org\n    source: "+orgRepo+"#main\n"+
+				"adapters:\n"+adapters), 0644))
+	}
+	writePromptsfile("  agents:\n    enabled: true\n")
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("rules are composed into the managed section", func(t *testing.T) {
+		agents := read("AGENTS.md")
+		assert.True(t, strings.HasPrefix(agents, "# Hand-written notes\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\n"))
+		assert.Less(t, strings.Index(agents, "# Org tone"), strings.Index(agents, "# Project style"), "lower scopes come first")
+		assert.NotContains(t, agents, "Tag and publish", "commands are not rules")
+		assert.NotContains(t, agents, "# API rules")
+
+		assert.Contains(t, read("services/api/AGENTS.md"), "# API rules")
+		assert.True(t, lockedFile(t, workDir, "prompts/api.md").Section)
+		assert.NotContains(t, read(".gitignore"), "AGENTS.md")
+	})
+
+	t.Run("drift is only detected inside the managed section", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+		verify := func() error {
+			return runInstall(t, workDir, cacheDir, workflow.InstallOptions{VerifyOnly: true})
+		}
+
+		original := read("AGENTS.md")
+		require.NoError(t, os.WriteFile("AGENTS.md", []byte(original+"\nMore notes\n"), 0644))
+		require.NoError(t, verify())
+
+		require.NoError(t, os.WriteFile("AGENTS.md", []byte(strings.Replace(original, "# Org tone", "# Edited", 1)), 0644))
+		err := verify()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "drift detected")
+
+		require.NoError(t, os.WriteFile("AGENTS.md", []byte(original+"\nMore notes\n"), 0644))
+	})
+
+	t.Run("reinstall keeps hand-written content", func(t *testing.T) {
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+		agents := read("AGENTS.md")
+		assert.Equal(t, 1, strings.Count(agents, "<!-- BEGIN PROMPT-SYNC MANAGED -->"))
+		assert.Contains(t, agents, "More notes")
+	})
+
+	t.Run("disabling the adapter removes only the managed sections", func(t *testing.T) {
+		writePromptsfile("  cursor:\n    enabled: true\n")
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		assert.Equal(t, "# Hand-written notes\n\nMore notes\n", read("AGENTS.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, "services/api/AGENTS.md"))
+	})
+}
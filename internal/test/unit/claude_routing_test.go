@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_ClaudeRoutesByKind(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/review.md":                "# Review\n",
+		"prompts/deploy.md":                "---\nkind: command\n---\nDeploy.\n",
+		"prompts/tester.md":                "---\nkind: agent\nname: tester\n---\nYou write tests.\n",
+		"prompts/changelog.md":             "---\nkind: skill\n---\nWrite changelogs.\n",
+		"prompts/pdf/SKILL.md":             "---\nkind: skill\nname: pdf\n---\nSee reference.md.\n",
+		"prompts/pdf/reference.md":         "# PDF reference\n",
+		"prompts/pdf/scripts/extract.py":   "print('extract')\n",
+		"prompts/conventions.md":           "---\nkind: rule\n---\n# Conventions\n",
+		"prompts/other/not_a_skill_dir.md": "---\nkind: command\n---\nNested.\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "CLAUDE.md"), []byte("# Hand-written notes\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\nadapters:\n  claude:\n    enabled: true\n    prefix: acme\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("commands and agents", func(t *testing.T) {
+		assert.Equal(t, "# Review\n", read(".claude/commands/acme-review.md"), "commands are the default kind")
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-deploy.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-not-a-skill-dir.md"))
+		assert.Equal(t, "---\nkind: agent\nname: tester\n---\nYou write tests.\n", read(".claude/agents/acme-tester.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".claude/commands/acme-tester.md"))
+	})
+
+	t.Run("skills get their own directory", func(t *testing.T) {
+		assert.Equal(t, "---\nkind: skill\n---\nWrite changelogs.\n", read(".claude/skills/acme-changelog/SKILL.md"))
+
+		assert.Contains(t, read(".claude/skills/acme-pdf/SKILL.md"), "See reference.md.")
+		assert.Equal(t, "# PDF reference\n", read(".claude/skills/acme-pdf/reference.md"))
+		assert.Equal(t, "print('extract')\n", read(".claude/skills/acme-pdf/scripts/extract.py"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".claude/commands/acme-reference.md"), "supporting files are not commands")
+		assert.Equal(t, ".claude/skills/acme-pdf/scripts/extract.py", lockedFile(t, workDir, "prompts/pdf/scripts/extract.py").Path)
+	})
+
+	t.Run("rules go to the managed section of CLAUDE.md", func(t *testing.T) {
+		memory := read("CLAUDE.md")
+		assert.Contains(t, memory, "# Hand-written notes\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\n")
+		assert.Contains(t, memory, "# Conventions\n")
+		assert.True(t, lockedFile(t, workDir, "prompts/conventions.md").Section)
+	})
+
+	t.Run("every location is ignored per prefix", func(t *testing.T) {
+		gitignore := read(".gitignore")
+		assert.Contains(t, gitignore, ".claude/commands/acme-*")
+		assert.Contains(t, gitignore, ".claude/agents/acme-*")
+		assert.Contains(t, gitignore, ".claude/skills/acme-*/")
+		assert.NotContains(t, gitignore, "CLAUDE.md")
+	})
+
+	t.Run("verify passes on the installed files", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{VerifyOnly: true}))
+	})
+}
+
+func TestInstall_RuleAdaptersSkipSkills(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/pdf/SKILL.md":       "---\nname: pdf\n---\nRead PDFs.\n",
+		"prompts/pdf/reference.md":   "# PDF reference\n",
+		"prompts/excel/SKILL.md":     "---\nkind: skill\nname: excel\n---\nRead spreadsheets.\n",
+		"prompts/tester.md":          "---\nkind: agent\n---\nYou write tests.\n",
+		"prompts/conventions.md":     "# Conventions\n",
+		"prompts/excel/reference.md": "# Excel reference\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\nadapters:\n  cursor:\n    enabled: true\n  copilot:\n    enabled: true\n  claude:\n    enabled: true\n    prefix: acme\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	assert.FileExists(t, filepath.Join(workDir, ".claude/skills/acme-pdf/SKILL.md"))
+	assert.FileExists(t, filepath.Join(workDir, ".claude/skills/acme-excel/reference.md"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/conventions.mdc"))
+	assert.FileExists(t, filepath.Join(workDir, ".github/instructions/conventions.instructions.md"))
+
+	for _, path := range []string{
+		".cursor/rules/_active/SKILL.mdc",
+		".cursor/rules/_active/reference.mdc",
+		".cursor/rules/_active/tester.mdc",
+		".github/instructions/SKILL.instructions.md",
+		".github/instructions/tester.instructions.md",
+	} {
+		assert.NoFileExists(t, filepath.Join(workDir, path))
+	}
+}
+
+func TestInstall_ClaudeDirectorySkillExpandsIncludes(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"partials/tone.md":         "Be concise.\n",
+		"prompts/pdf/SKILL.md":     "---\nname: pdf\n---\nRead PDFs.\n{{> partials/tone.md}}\n",
+		"prompts/pdf/reference.md": "# PDF reference\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\nadapters:\n  claude:\n    enabled: true\n    prefix: acme\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	skill, err := os.ReadFile(filepath.Join(workDir, ".claude/skills/acme-pdf/SKILL.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nname: pdf\n---\nRead PDFs.\nBe concise.\n", string(skill))
+
+	reference, err := os.ReadFile(filepath.Join(workDir, ".claude/skills/acme-pdf/reference.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# PDF reference\n", string(reference), "supporting files are copied from disk")
+	assert.Equal(t, []string{"partials/tone.md"}, lockedFile(t, workDir, "prompts/pdf/SKILL.md").Includes)
+}
@@ -25,7 +25,7 @@ func TestLoadConfig_Precedence(t *testing.T) {
 
 	// Project Promptsfile with an overriding "shared" source and a new one.
 	promptsfilePath := filepath.Join(tempDir, "Promptsfile")
-	writeFile(t, promptsfilePath, `sources:
+	writeFile(t, promptsfilePath, `trusted_sources:
   - name: project
     repo: git@github.com:proj/prompts.git
   - name: shared
@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_CopilotAdapter(t *testing.T) {
+	orgRepo := createPromptRepo(t, map[string]string{
+		"prompts/tone.md": "---\nalwaysApply: true\n---\n# Org tone\n",
+	})
+	projectRepo := createPromptRepo(t, map[string]string{
+		"prompts/style.md":   "---\nalwaysApply: true\n---\n# Project style\n",
+		"prompts/go.md":      "---\ndescription: Go conventions\nglobs: '**/*.go, **/go.mod'\n---\n# Go\n",
+		"prompts/release.md": "---\nkind: command\ndescription: Cut a release\n---\nTag and publish.\n",
+	})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, ".github"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, ".github/copilot-instructions.md"), []byte("# Hand-written instructions\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+projectRepo+"#main\n"+
+			"overlays:\n  - scope: org\n    source: "+orgRepo+"#main\n"+
+			"adapters:\n  copilot:\n    enabled: true\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("always-on rules from every source share the instructions file", func(t *testing.T) {
+		instructions := read(".github/copilot-instructions.md")
+		assert.True(t, strings.HasPrefix(instructions, "# Hand-written instructions\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\n"), "hand-written content is kept")
+		assert.Contains(t, instructions, "<!-- prompt-sync: begin "+orgRepo+" prompts/tone.md -->\n# Org tone\n<!-- prompt-sync: end")
+		assert.Contains(t, instructions, "<!-- prompt-sync: begin "+projectRepo+" prompts/style.md -->\n# Project style\n")
+		assert.Less(t, strings.Index(instructions, "# Org tone"), strings.Index(instructions, "# Project style"), "lower scopes come first")
+
+		assert.Equal(t, ".github/copilot-instructions.md", lockedFile(t, workDir, "prompts/tone.md").Path)
+		assert.Equal(t, ".github/copilot-instructions.md", lockedFile(t, workDir, "prompts/style.md").Path)
+		assert.True(t, lockedFile(t, workDir, "prompts/style.md").Section)
+		assert.NotContains(t, read(".gitignore"), "copilot-instructions.md", "the instructions file stays under version control")
+	})
+
+	t.Run("scoped rules and commands get their own files", func(t *testing.T) {
+		assert.Equal(t, "---\ndescription: Go conventions\napplyTo: '**/*.go,**/go.mod'\n---\n\n# Go\n", read(".github/instructions/go.instructions.md"))
+		assert.Equal(t, "---\ndescription: Cut a release\n---\n\nTag and publish.\n", read(".github/prompts/release.prompt.md"))
+		assert.Contains(t, read(".gitignore"), ".github/instructions/*.instructions.md")
+	})
+
+	t.Run("verify passes on the composed file", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{VerifyOnly: true}))
+	})
+
+	t.Run("removed prompts are cleaned up", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+			"sources:\n  - "+projectRepo+"#main\nadapters:\n  copilot:\n    enabled: true\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		instructions := read(".github/copilot-instructions.md")
+		assert.NotContains(t, instructions, "# Org tone")
+		assert.Contains(t, instructions, "# Project style")
+		assert.FileExists(t, filepath.Join(workDir, ".github/prompts/release.prompt.md"))
+	})
+
+	t.Run("disabling the adapter removes only the managed section", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+			"sources:\n  - "+projectRepo+"#main\nadapters:\n  cursor:\n    enabled: true\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		assert.Equal(t, "# Hand-written instructions\n", read(".github/copilot-instructions.md"))
+	})
+}
@@ -6,7 +6,7 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/kovyrin/prompt-sync/internal/adapter/cursor"
+	"github.com/kovyrin/prompt-sync/internal/adapter"
 )
 
 func TestCursorAdapter_MetadataMerge(t *testing.T) {
@@ -99,7 +99,7 @@ func TestCursorAdapter_MetadataMerge(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cursor.MergeMetadata(tt.defaults, tt.fileOverride, tt.frontMatter)
+			result := adapter.MergeMetadata(tt.defaults, tt.fileOverride, tt.frontMatter)
 			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("MergeMetadata() = %v, want %v", result, tt.expected)
 			}
@@ -167,7 +167,7 @@ Body`,
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			meta, body, err := cursor.ParseFrontMatter([]byte(tt.content))
+			meta, body, err := adapter.ParseFrontMatter([]byte(tt.content))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseFrontMatter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -206,7 +206,7 @@ files:
 		t.Fatalf("failed to write metadata file: %v", err)
 	}
 
-	metadata, err := cursor.LoadMetadataFile(metadataPath)
+	metadata, err := adapter.LoadMetadataFile(metadataPath)
 	if err != nil {
 		t.Fatalf("LoadMetadataFile() error = %v", err)
 	}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/adapter/cursor"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_CursorRules(t *testing.T) {
+	install := func(t *testing.T, files map[string]string) (string, error) {
+		repo := createPromptRepo(t, files)
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nadapters:\n  cursor:\n    enabled: true\n"), 0644))
+		return workDir, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{})
+	}
+
+	t.Run("rule fields are normalized into .mdc files", func(t *testing.T) {
+		workDir, err := install(t, map[string]string{
+			"prompts/metadata.yaml": "defaults:\n  description: Team conventions\n",
+			"prompts/go.md":         "---\nglobs: '**/*.go, **/go.mod'\nalwaysApply: 'false'\npriority: high\n---\n# Go\n",
+			"prompts/style.mdc":     "---\nglobs: ['*.css']\nalwaysApply: true\n---\n# Style\n",
+			"prompts/plain.md":      "---\ndescription: null\n---\n# Plain\n",
+			"prompts/guide.md":      "---\ntitle: Guide\nsettings:\n  strict_mode: true\n---\n# Guide\n",
+		})
+		require.NoError(t, err)
+
+		read := func(path string) string {
+			content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active", path))
+			require.NoError(t, err)
+			return string(content)
+		}
+		assert.Equal(t, "---\ndescription: Team conventions\nglobs: **/*.go,**/go.mod\nalwaysApply: false\npriority: high\n---\n\n# Go\n", read("go.mdc"), "unknown fields are kept after the rule fields")
+		assert.Equal(t, "---\ndescription: Team conventions\nglobs: *.css\nalwaysApply: true\n---\n\n# Style\n", read("style.mdc"))
+		assert.Equal(t, "# Plain\n", read("plain.mdc"), "rules without rule fields have no front-matter")
+		assert.Equal(t, "---\ndescription: Team conventions\nalwaysApply: false\nsettings:\n    strict_mode: true\ntitle: Guide\n---\n\n# Guide\n", read("guide.mdc"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/go.md"))
+	})
+
+	t.Run("invalid rule fields fail the install", func(t *testing.T) {
+		_, err := install(t, map[string]string{
+			"prompts/bad.md": "---\nalwaysApply: sometimes\n---\n# Bad\n",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `prompts/bad.md: invalid alwaysApply "sometimes": must be true or false`)
+
+		_, err = install(t, map[string]string{
+			"prompts/bad.md": "---\nglobs: {go: true}\n---\n# Bad\n",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid globs: must be a list or a comma separated string")
+	})
+}
+
+func TestCursorAdapter_CollectsUnknownFieldWarnings(t *testing.T) {
+	packDir := t.TempDir()
+	writeFile(t, filepath.Join(packDir, "prompts/guide.md"), "---\ntitle: Guide\npriority: high\n---\n# Guide\n")
+
+	pack := adapter.PromptPack{Name: "acme", Path: packDir, Warnings: &adapter.Warnings{}}
+	_, err := cursor.NewAdapter("").Render(pack, adapter.ScopeProject)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`prompts/guide.md: unknown Cursor rule field "priority"`,
+		`prompts/guide.md: unknown Cursor rule field "title"`,
+	}, pack.Warnings.Messages())
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/adapter/gemini"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestGeminiRenderCommand(t *testing.T) {
+	rendered := gemini.RenderCommand(`Review "staged" changes`, []byte("Review $ARGUMENTS.\nUse C:\\tmp.\n"))
+	assert.Equal(t, "description = \"Review \\\"staged\\\" changes\"\nprompt = \"\"\"\nReview {{args}}.\nUse C:\\\\tmp.\n\"\"\"\n", string(rendered))
+}
+
+func TestGeminiRenderCommand_PositionalArguments(t *testing.T) {
+	rendered := gemini.RenderCommand("", []byte("Fix issue $1 with priority $2, not $10 or $$.\n"))
+	assert.Equal(t, "prompt = \"\"\"\nFix issue {{args}} with priority {{args}}, not $10 or $$.\n\"\"\"\n", string(rendered))
+
+	packDir := t.TempDir()
+	writeFile(t, filepath.Join(packDir, "prompts/fix.md"), "---\nkind: command\n---\nFix issue $1.\n")
+	writeFile(t, filepath.Join(packDir, "prompts/review.md"), "---\nkind: command\n---\nReview $ARGUMENTS.\n")
+
+	pack := adapter.PromptPack{Name: "acme", Path: packDir, Warnings: &adapter.Warnings{}}
+	_, err := gemini.NewAdapter("").Render(pack, adapter.ScopeProject)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"prompts/fix.md: Gemini CLI has no positional arguments, $1-$9 are replaced with all of them ({{args}})",
+	}, pack.Warnings.Messages())
+}
+
+func TestInstall_GeminiAdapter(t *testing.T) {
+	orgRepo := createPromptRepo(t, map[string]string{"prompts/tone.md": "# Org tone\n"})
+	projectRepo := createPromptRepo(t, map[string]string{
+		"prompts/style.md":   "# Project style\n",
+		"prompts/release.md": "---\nkind: command\ndescription: Cut a release\n---\nTag $ARGUMENTS and publish.\n",
+	})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "GEMINI.md"), []byte("# Hand-written notes\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+projectRepo+"#main\n"+
+			"overlays:\n  - scope: org\n    source: "+orgRepo+"#main\n"+
+			"adapters:\n  gemini:\n    enabled: true\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("rules are composed into the managed section of GEMINI.md", func(t *testing.T) {
+		context := read("GEMINI.md")
+		assert.True(t, strings.HasPrefix(context, "# Hand-written notes\n\n<!-- BEGIN PROMPT-SYNC MANAGED -->\n"))
+		assert.Less(t, strings.Index(context, "# Org tone"), strings.Index(context, "# Project style"), "lower scopes come first")
+		assert.NotContains(t, context, "Tag")
+		assert.True(t, lockedFile(t, workDir, "prompts/style.md").Section)
+	})
+
+	t.Run("commands become TOML commands", func(t *testing.T) {
+		assert.Equal(t, "description = \"Cut a release\"\nprompt = \"\"\"\nTag {{args}} and publish.\n\"\"\"\n", read(".gemini/commands/release.toml"))
+		assert.Equal(t, ".gemini/commands/release.toml", lockedFile(t, workDir, "prompts/release.md").Path)
+
+		gitignore := read(".gitignore")
+		assert.Contains(t, gitignore, ".gemini/commands/*.toml")
+		assert.NotContains(t, gitignore, "GEMINI.md")
+	})
+
+	t.Run("verify passes on the installed files", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{VerifyOnly: true}))
+	})
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/git"
+)
+
+func TestGitCache_PerCommitSnapshots(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/style.md": "v1\n"})
+	runGitCmd(t, repo, "tag", "v1.0.0")
+	commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "v2\n"}, "Update style")
+
+	readStyle := func(t *testing.T, path string) string {
+		content, err := os.ReadFile(filepath.Join(path, "prompts/style.md"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	for _, backend := range []git.Backend{git.BackendGoGit, git.BackendExec} {
+		t.Run(string(backend), func(t *testing.T) {
+			cacheDir := t.TempDir()
+			fetcher := git.NewFetcherWithBackend(backend, git.WithCacheDir(cacheDir))
+
+			oldPath, oldCommit, err := fetcher.CloneOrUpdate(repo, "v1.0.0")
+			require.NoError(t, err)
+			newPath, newCommit, err := fetcher.CloneOrUpdate(repo, "main")
+			require.NoError(t, err)
+
+			assert.NotEqual(t, oldCommit, newCommit)
+			assert.NotEqual(t, oldPath, newPath, "each commit gets its own snapshot")
+			assert.Equal(t, "v1\n", readStyle(t, oldPath), "checking out another ref must not touch earlier snapshots")
+			assert.Equal(t, "v2\n", readStyle(t, newPath))
+
+			cached, ok := fetcher.CachedPath(repo, "v1.0.0")
+			require.True(t, ok)
+			assert.Equal(t, oldPath, cached)
+			assert.Equal(t, "v1\n", readStyle(t, cached))
+
+			lockedPath, err := fetcher.CheckoutCommit(repo, oldCommit)
+			require.NoError(t, err)
+			assert.Equal(t, oldPath, lockedPath, "the same commit reuses its snapshot")
+
+			_, ok = fetcher.CachedPath(repo, "no-such-ref")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestGitCache_BranchMovesToNewSnapshot(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/style.md": "v1\n"})
+
+	for _, backend := range []git.Backend{git.BackendGoGit, git.BackendExec} {
+		t.Run(string(backend), func(t *testing.T) {
+			fetcher := git.NewFetcherWithBackend(backend, git.WithCacheDir(t.TempDir()))
+
+			firstPath, firstCommit, err := fetcher.CloneOrUpdate(repo, "main")
+			require.NoError(t, err)
+
+			latest := commitPromptFiles(t, repo, map[string]string{"prompts/style.md": string(backend) + "\n"}, "Update")
+
+			secondPath, secondCommit, err := fetcher.CloneOrUpdate(repo, "main")
+			require.NoError(t, err)
+			assert.Equal(t, latest, secondCommit)
+			assert.NotEqual(t, firstCommit, secondCommit)
+			assert.NotEqual(t, firstPath, secondPath)
+
+			content, err := os.ReadFile(filepath.Join(firstPath, "prompts/style.md"))
+			require.NoError(t, err)
+			assert.NotEqual(t, string(backend)+"\n", string(content), "old snapshot keeps its commit")
+		})
+	}
+}
+
+func TestGitCache_ConcurrentCheckouts(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/style.md": "v1\n"})
+	runGitCmd(t, repo, "tag", "v1.0.0")
+	commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "v2\n"}, "Update style")
+	runGitCmd(t, repo, "tag", "v2.0.0")
+
+	for _, backend := range []git.Backend{git.BackendGoGit, git.BackendExec} {
+		t.Run(string(backend), func(t *testing.T) {
+			cacheDir := t.TempDir()
+
+			// Separate fetchers simulate installs running in different projects
+			refs := []string{"v1.0.0", "v2.0.0", "v1.0.0", "v2.0.0", "main", "main"}
+			paths := make([]string, len(refs))
+			errs := make([]error, len(refs))
+
+			var wg sync.WaitGroup
+			for i, ref := range refs {
+				wg.Add(1)
+				go func(i int, ref string) {
+					defer wg.Done()
+					fetcher := git.NewFetcherWithBackend(backend, git.WithCacheDir(cacheDir))
+					paths[i], _, errs[i] = fetcher.CloneOrUpdate(repo, ref)
+				}(i, ref)
+			}
+			wg.Wait()
+
+			want := map[string]string{"v1.0.0": "v1\n", "v2.0.0": "v2\n", "main": "v2\n"}
+			for i, ref := range refs {
+				require.NoError(t, errs[i], ref)
+				content, err := os.ReadFile(filepath.Join(paths[i], "prompts/style.md"))
+				require.NoError(t, err)
+				assert.Equal(t, want[ref], string(content), ref)
+			}
+		})
+	}
+}
+
+func TestGitCache_CheckoutCommitNotOnAnyBranch(t *testing.T) {
+	for _, backend := range []git.Backend{git.BackendGoGit, git.BackendExec} {
+		t.Run(string(backend), func(t *testing.T) {
+			repo := createPromptRepo(t, map[string]string{"prompts/style.md": "v1\n"})
+			runGitCmd(t, repo, "checkout", "-b", "feature")
+			orphaned := commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "feature\n"}, "Feature style")
+			runGitCmd(t, repo, "checkout", "main")
+
+			fetcher := git.NewFetcherWithBackend(backend, git.WithCacheDir(t.TempDir()))
+			_, _, err := fetcher.CloneOrUpdate(repo, "main")
+			require.NoError(t, err)
+
+			// The cache keeps the commit after the branch moves away from it
+			runGitCmd(t, repo, "branch", "-f", "feature", "main")
+			_, _, err = fetcher.CloneOrUpdate(repo, "main")
+			require.NoError(t, err)
+
+			path, err := fetcher.CheckoutCommit(repo, orphaned)
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filepath.Join(path, "prompts/style.md"))
+			require.NoError(t, err)
+			assert.Equal(t, "feature\n", string(content))
+		})
+	}
+}
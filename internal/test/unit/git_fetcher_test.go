@@ -211,3 +211,19 @@ func TestGitFetcher_CacheDir(t *testing.T) {
 		}
 	})
 }
+
+func TestIsCommitHash(t *testing.T) {
+	for ref, want := range map[string]bool{
+		"abc1234": true,
+		"6b603d32bda00d1ca456903820ba48fcff1a8fd5": true,
+		"ABC1234": true,
+		"abc123":  false, // too short
+		"main":    false,
+		"v1.2.3":  false,
+		"6b603d32bda00d1ca456903820ba48fcff1a8fd5a": false, // too long
+	} {
+		if got := git.IsCommitHash(ref); got != want {
+			t.Errorf("IsCommitHash(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestExpandIncludes(t *testing.T) {
+	pack := t.TempDir()
+	shared := t.TempDir()
+	write := func(root, path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+	write(pack, "partials/tone.md", "---\nsecurity: low\n---\nBe concise.\n")
+	write(pack, "partials/header.md", "# Team rules\n{{> partials/tone.md}}\n")
+	write(pack, "partials/a.md", "{{> partials/b.md}}\n")
+	write(pack, "partials/b.md", "{{> partials/a.md}}\n")
+	write(shared, "partials/review.md", "{{> partials/checklist.md}}\n")
+	write(shared, "partials/checklist.md", "- tests pass\n")
+	packs := map[string]string{"shared": shared}
+
+	t.Run("directives and include lists are expanded recursively", func(t *testing.T) {
+		meta := map[string]interface{}{"include": []interface{}{"partials/header.md"}}
+		body, includes, err := adapter.ExpandIncludes(pack, "prompts/review.md", meta, []byte("Review:\n{{> shared:partials/review.md}}\n"), packs)
+		require.NoError(t, err)
+		assert.Equal(t, "# Team rules\nBe concise.\n\nReview:\n- tests pass\n", string(body))
+		assert.Equal(t, []string{"partials/header.md", "partials/tone.md", "shared:partials/checklist.md", "shared:partials/review.md"}, includes)
+	})
+
+	t.Run("cycles are reported", func(t *testing.T) {
+		_, _, err := adapter.ExpandIncludes(pack, "prompts/loop.md", nil, []byte("{{> partials/a.md}}"), packs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include cycle: prompts/loop.md -> partials/a.md -> partials/b.md -> partials/a.md")
+	})
+
+	t.Run("includes stay inside known packs", func(t *testing.T) {
+		_, _, err := adapter.ExpandIncludes(pack, "prompts/x.md", nil, []byte("{{> ../secret.md}}"), packs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include ../secret.md must be a path inside the pack")
+
+		_, _, err = adapter.ExpandIncludes(pack, "prompts/x.md", nil, []byte("{{> other:partials/tone.md}}"), packs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `include other:partials/tone.md names unknown source "other"`)
+	})
+}
+
+func TestInstall_Includes(t *testing.T) {
+	shared := createPromptRepo(t, map[string]string{
+		"partials/tone.md": "Be concise.\n",
+	})
+	repo := createPromptRepo(t, map[string]string{
+		"partials/footer.md": "Thanks, {{.team}}.\n",
+		"prompts/review.md":  "---\ninclude: [shared:partials/tone.md]\ntemplate: true\n---\n# Review\n{{> partials/footer.md}}\n",
+	})
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("sources:\n  - name: shared\n    repo: "+shared+"\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\n  - "+shared+"#main\nvars:\n  team: platform\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	rulePath := filepath.Join(workDir, ".cursor/rules/_active/review.mdc")
+	content, err := os.ReadFile(rulePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Be concise.\n\n# Review\nThanks, platform.\n", string(content), "partials are expanded before templating")
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/footer.mdc"), "partials are not rendered on their own")
+
+	file := lockedFile(t, workDir, "prompts/review.md")
+	assert.Equal(t, []string{"partials/footer.md", "shared:partials/tone.md"}, file.Includes)
+
+	t.Run("changes to a partial are picked up and verified", func(t *testing.T) {
+		commitPromptFiles(t, repo, map[string]string{"partials/footer.md": "Cheers, {{.team}}.\n"}, "Update footer")
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}}))
+
+		content, err := os.ReadFile(rulePath)
+		require.NoError(t, err)
+		assert.Equal(t, "Be concise.\n\n# Review\nCheers, platform.\n", string(content))
+
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{VerifyOnly: true}))
+
+		hash, err := lock.New(workDir).CalculateFileHash(rulePath)
+		require.NoError(t, err)
+		assert.Equal(t, hash, lockedFile(t, workDir, "prompts/review.md").Hash)
+	})
+}
+
+func TestInstall_IncludesFromSubdirectorySource(t *testing.T) {
+	shared := createPromptRepo(t, map[string]string{
+		"packs/shared/partials/tone.md": "Be concise.\n",
+		"partials/tone.md":              "Repository root.\n",
+	})
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/review.md": "# Review\n{{> shared:partials/tone.md}}\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(`version: 1
+sources:
+  - `+repo+`#main
+  - name: shared
+    repo: `+shared+`
+    ref: main
+    subdirectory: packs/shared
+`), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/review.mdc"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Review\nBe concise.\n", string(content), "includes of other sources are relative to their pack root")
+}
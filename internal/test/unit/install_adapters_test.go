@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_RendersThroughAgentAdapters(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/metadata.yaml":  "defaults:\n  alwaysApply: false\nfiles:\n  style.md:\n    globs: ['**/*.go']\n",
+		"prompts/style.md":       "---\nalwaysApply: true\n---\n# Style\n",
+		"prompts/code_review.md": "# Review\n",
+	})
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("sources:\n  - name: TeamPrompts\n    repo: "+repo+"\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	newWorkspace := func(t *testing.T, adapters string) string {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nadapters:\n"+adapters), 0644))
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+		return workDir
+	}
+
+	t.Run("cursor rules merge metadata.yaml with front-matter", func(t *testing.T) {
+		workDir := newWorkspace(t, "  cursor:\n    enabled: true\n")
+
+		style, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "---\nglobs: **/*.go\nalwaysApply: true\n---\n\n# Style\n", string(style))
+
+		review, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/code_review.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "---\nalwaysApply: false\n---\n\n# Review\n", string(review))
+
+		assert.Equal(t, "prompts/style.md", lockedFile(t, workDir, "prompts/style.md").SourcePath)
+	})
+
+	t.Run("claude commands are prefixed with the trusted source name", func(t *testing.T) {
+		workDir := newWorkspace(t, "  claude:\n    enabled: true\n")
+
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/team-prompts-code-review.md"))
+		gitignore, err := os.ReadFile(filepath.Join(workDir, ".gitignore"))
+		require.NoError(t, err)
+		assert.Contains(t, string(gitignore), ".claude/commands/team-prompts-*")
+	})
+
+	t.Run("configured prefix wins over the source name", func(t *testing.T) {
+		workDir := newWorkspace(t, "  claude:\n    enabled: true\n    prefix: acme\n")
+
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-style.md"))
+		assert.Equal(t, ".claude/commands/acme-style.md", lockedFile(t, workDir, "prompts/style.md").Path)
+	})
+}
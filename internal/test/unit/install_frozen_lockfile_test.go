@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+// createPromptRepo creates a local git repository on branch main containing
+// the given prompt files and returns its path.
+func createPromptRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	repoDir := t.TempDir()
+	runGitCmd(t, repoDir, "init")
+	runGitCmd(t, repoDir, "checkout", "-b", "main")
+	runGitCmd(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCmd(t, repoDir, "config", "user.name", "Test User")
+	commitPromptFiles(t, repoDir, files, "Initial commit")
+
+	return repoDir
+}
+
+// commitPromptFiles writes files into the repository and commits them,
+// returning the new commit hash.
+func commitPromptFiles(t *testing.T, repoDir string, files map[string]string, message string) string {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(repoDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	runGitCmd(t, repoDir, "add", "-A")
+	runGitCmd(t, repoDir, "commit", "-m", message)
+
+	return runGitCmd(t, repoDir, "rev-parse", "HEAD")
+}
+
+func runGitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), output)
+
+	return strings.TrimSpace(string(output))
+}
+
+func runInstall(t *testing.T, workDir, cacheDir string, opts workflow.InstallOptions) error {
+	t.Helper()
+
+	opts.WorkspaceDir = workDir
+	opts.CacheDir = cacheDir
+	opts.AllowUnknown = true
+
+	installer, err := workflow.New(opts)
+	require.NoError(t, err)
+
+	return installer.Execute()
+}
+
+func TestInstall_LockedCommits(t *testing.T) {
+	t.Run("reinstall checks out the locked commit", func(t *testing.T) {
+		repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style v1\n"})
+		workDir := t.TempDir()
+		cacheDir := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		require.Len(t, lockData.Sources, 1)
+		lockedCommit := lockData.Sources[0].Commit
+
+		// Upstream moves on after the lock was written
+		newCommit := commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "# Style v2\n"}, "Update style")
+		require.NotEqual(t, lockedCommit, newCommit)
+
+		// Remove the rendered output and reinstall from the lock
+		require.NoError(t, os.RemoveAll(filepath.Join(workDir, ".cursor")))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v1\n", string(content), "install should reproduce the locked commit")
+
+		lockData, err = lock.New(workDir).Read()
+		require.NoError(t, err)
+		assert.Equal(t, lockedCommit, lockData.Sources[0].Commit)
+	})
+
+	t.Run("reinstall with a fresh cache fetches the locked commit", func(t *testing.T) {
+		repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style v1\n"})
+		workDir := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+		commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "# Style v2\n"}, "Update style")
+
+		// A teammate with an empty cache gets the same prompts
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{FrozenLockfile: true}))
+
+		content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v1\n", string(content))
+	})
+}
+
+func TestInstall_FrozenLockfile(t *testing.T) {
+	t.Run("fails without a lock file", func(t *testing.T) {
+		repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style\n"})
+		workDir := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{FrozenLockfile: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Promptsfile.lock not found")
+	})
+
+	t.Run("fails when sources are added, removed or changed", func(t *testing.T) {
+		repo1 := createPromptRepo(t, map[string]string{"prompts/one.md": "# One\n"})
+		repo2 := createPromptRepo(t, map[string]string{"prompts/two.md": "# Two\n"})
+		workDir := t.TempDir()
+		cacheDir := t.TempDir()
+		promptsfile := filepath.Join(workDir, "Promptsfile")
+
+		require.NoError(t, os.WriteFile(promptsfile, []byte("sources:\n  - "+repo1+"#main\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		// Added source
+		require.NoError(t, os.WriteFile(promptsfile, []byte("sources:\n  - "+repo1+"#main\n  - "+repo2+"#main\n"), 0644))
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{FrozenLockfile: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), repo2+" is not in the lock file")
+
+		// Removed source
+		require.NoError(t, os.WriteFile(promptsfile, []byte("sources:\n  - "+repo2+"#main\n"), 0644))
+		err = runInstall(t, workDir, cacheDir, workflow.InstallOptions{FrozenLockfile: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), repo1+" was removed from Promptsfile")
+
+		// Changed ref
+		require.NoError(t, os.WriteFile(promptsfile, []byte("sources:\n  - "+repo1+"#develop\n"), 0644))
+		err = runInstall(t, workDir, cacheDir, workflow.InstallOptions{FrozenLockfile: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ref changed from "main" to "develop"`)
+
+		// Nothing was rendered for the rejected installs
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/two.mdc"))
+	})
+
+	t.Run("succeeds when Promptsfile matches the lock", func(t *testing.T) {
+		repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style\n"})
+		workDir := t.TempDir()
+		cacheDir := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{FrozenLockfile: true}))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+	})
+}
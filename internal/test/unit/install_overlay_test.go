@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/cmd"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_OverlayShadowing(t *testing.T) {
+	orgRepo := createPromptRepo(t, map[string]string{
+		"prompts/style.md":  "# Org style\n",
+		"prompts/review.md": "# Org review\n",
+	})
+	personalRepo := createPromptRepo(t, map[string]string{"prompts/style.md": "# My style\n"})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	stylePath := filepath.Join(workDir, ".cursor/rules/_active/style.mdc")
+
+	writeOverlays := func(overlays string) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte("overlays:\n"+overlays), 0644))
+	}
+	orgOverlay := "  - scope: org\n    source: " + orgRepo + "#main\n"
+	personalOverlay := "  - scope: personal\n    source: " + personalRepo + "#main\n"
+
+	readStyle := func() string {
+		content, err := os.ReadFile(stylePath)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	writeOverlays(orgOverlay)
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+	assert.Equal(t, "# Org style\n", readStyle())
+
+	t.Run("personal overlay shadows the org file", func(t *testing.T) {
+		writeOverlays(personalOverlay + orgOverlay)
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+		assert.Equal(t, "# My style\n", readStyle())
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/review.mdc"), "other org files are kept")
+	})
+
+	t.Run("list shows shadowed files", func(t *testing.T) {
+		t.Cleanup(func() { _ = cmd.ListCmd.Flags().Set("json", "false") })
+
+		output := &bytes.Buffer{}
+		require.NoError(t, runListCommand(workDir, []string{"--json"}, output))
+
+		var result struct {
+			Sources []struct {
+				URL           string `json:"url"`
+				Scope         string `json:"scope"`
+				ShadowedFiles []struct {
+					Path            string `json:"path"`
+					ShadowedBy      string `json:"shadowed_by"`
+					ShadowedByScope string `json:"shadowed_by_scope"`
+				} `json:"shadowed_files"`
+			} `json:"sources"`
+		}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+		require.Len(t, result.Sources, 2)
+
+		for _, source := range result.Sources {
+			switch source.URL {
+			case personalRepo:
+				assert.Equal(t, "personal", source.Scope)
+				assert.Empty(t, source.ShadowedFiles)
+			case orgRepo:
+				assert.Equal(t, "org", source.Scope)
+				require.Len(t, source.ShadowedFiles, 1)
+				assert.Equal(t, ".cursor/rules/_active/style.mdc", source.ShadowedFiles[0].Path)
+				assert.Equal(t, personalRepo, source.ShadowedFiles[0].ShadowedBy)
+				assert.Equal(t, "personal", source.ShadowedFiles[0].ShadowedByScope)
+			}
+		}
+	})
+
+	t.Run("removing the overlay restores the org file", func(t *testing.T) {
+		writeOverlays(orgOverlay)
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+		assert.Equal(t, "# Org style\n", readStyle())
+	})
+
+	t.Run("same scope is still a conflict", func(t *testing.T) {
+		writeOverlays(orgOverlay + "  - scope: org\n    source: " + personalRepo + "#main\n")
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{})
+		assert.ErrorContains(t, err, "would be rendered by both")
+	})
+
+	t.Run("unknown scope is rejected", func(t *testing.T) {
+		writeOverlays("  - scope: team\n    source: " + personalRepo + "#main\n")
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{})
+		assert.ErrorContains(t, err, `invalid overlay scope "team"`)
+	})
+}
@@ -0,0 +1,213 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/git"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+// concurrencyTrackingFetcher serves local directories and records how many
+// fetches were in flight at the same time.
+type concurrencyTrackingFetcher struct {
+	repos map[string]string // URL -> local path
+	delay time.Duration
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (m *concurrencyTrackingFetcher) fetch(url string) (string, error) {
+	m.mu.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mu.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+
+	path, ok := m.repos[url]
+	if !ok {
+		return "", fmt.Errorf("repository not found: %s", url)
+	}
+	return path, nil
+}
+
+func (m *concurrencyTrackingFetcher) CloneOrUpdate(url, ref string) (string, string, error) {
+	path, err := m.fetch(url)
+	return path, "commit-" + filepath.Base(url), err
+}
+
+func (m *concurrencyTrackingFetcher) CheckoutCommit(url, commit string) (string, error) {
+	return m.fetch(url)
+}
+
+func (m *concurrencyTrackingFetcher) Clone(url, ref string) (string, error) {
+	return m.fetch(url)
+}
+
+func (m *concurrencyTrackingFetcher) Update(url, ref string) error {
+	return nil
+}
+
+func (m *concurrencyTrackingFetcher) CachedPath(url, ref string) (string, bool) {
+	path, ok := m.repos[url]
+	return path, ok
+}
+
+func (m *concurrencyTrackingFetcher) ResolveRemote(url, ref string) (*git.RemoteRef, error) {
+	return nil, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *concurrencyTrackingFetcher) CommitsBehind(url, from, to string) (int, error) {
+	return 0, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+// setupParallelSources creates one prompt directory per source and a
+// Promptsfile listing them in reverse alphabetical order.
+func setupParallelSources(t *testing.T, workDir string, files map[string]string) *concurrencyTrackingFetcher {
+	t.Helper()
+
+	fetcher := &concurrencyTrackingFetcher{repos: make(map[string]string), delay: 20 * time.Millisecond}
+
+	var urls []string
+	for url, file := range files {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "prompts"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "prompts", file), []byte("# "+url+"\n"), 0644))
+		fetcher.repos[url] = dir
+		urls = append(urls, url)
+	}
+
+	// Reverse order so that lock file sorting is observable
+	sort.Sort(sort.Reverse(sort.StringSlice(urls)))
+
+	promptsfile := "sources:\n"
+	for _, url := range urls {
+		promptsfile += "  - " + url + "\n"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(promptsfile), 0644))
+
+	return fetcher
+}
+
+func runParallelInstall(t *testing.T, workDir string, fetcher git.Fetcher, jobs int) error {
+	t.Helper()
+
+	installer, err := workflow.New(workflow.InstallOptions{
+		WorkspaceDir: workDir,
+		AllowUnknown: true,
+		Jobs:         jobs,
+	})
+	require.NoError(t, err)
+	installer.SetGitFetcher(fetcher)
+
+	return installer.Execute()
+}
+
+func TestInstall_ParallelSources(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("github.com/org/prompts-%d", i)] = fmt.Sprintf("prompt-%d.md", i)
+	}
+
+	t.Run("fetches with a bounded number of workers", func(t *testing.T) {
+		workDir := t.TempDir()
+		fetcher := setupParallelSources(t, workDir, files)
+
+		require.NoError(t, runParallelInstall(t, workDir, fetcher, 3))
+
+		assert.LessOrEqual(t, fetcher.maxInFlight, 3)
+		assert.Greater(t, fetcher.maxInFlight, 1, "sources should be fetched concurrently")
+
+		for _, file := range files {
+			assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active", strings.TrimSuffix(file, ".md")+".mdc"))
+		}
+	})
+
+	t.Run("a single job fetches sequentially", func(t *testing.T) {
+		workDir := t.TempDir()
+		fetcher := setupParallelSources(t, workDir, files)
+
+		require.NoError(t, runParallelInstall(t, workDir, fetcher, 1))
+		assert.Equal(t, 1, fetcher.maxInFlight)
+	})
+
+	t.Run("lock file order does not depend on the number of jobs", func(t *testing.T) {
+		var locks [][]lock.Source
+		for _, jobs := range []int{1, 8} {
+			workDir := t.TempDir()
+			fetcher := setupParallelSources(t, workDir, files)
+			require.NoError(t, runParallelInstall(t, workDir, fetcher, jobs))
+
+			lockData, err := lock.New(workDir).Read()
+			require.NoError(t, err)
+			for i := range lockData.Sources {
+				// Temp directories differ between runs, the rest must match
+				lockData.Sources[i].Files[0].Hash = ""
+			}
+			locks = append(locks, lockData.Sources)
+		}
+
+		assert.Equal(t, locks[0], locks[1])
+		require.Len(t, locks[0], len(files))
+		for i := 1; i < len(locks[0]); i++ {
+			assert.Less(t, locks[0][i-1].URL, locks[0][i].URL, "lock sources are sorted by URL")
+		}
+	})
+}
+
+func TestInstall_ParallelConflicts(t *testing.T) {
+	t.Run("conflicts across sources are detected before writing", func(t *testing.T) {
+		workDir := t.TempDir()
+		fetcher := setupParallelSources(t, workDir, map[string]string{
+			"github.com/org/b-prompts": "shared.md",
+			"github.com/org/a-prompts": "shared.md",
+			"github.com/org/c-prompts": "unique.md",
+		})
+
+		err := runParallelInstall(t, workDir, fetcher, 4)
+		require.Error(t, err)
+		// Promptsfile lists c, b, a: b is the first source to claim the path
+		assert.Contains(t, err.Error(), "shared.mdc would be rendered by both github.com/org/b-prompts and github.com/org/a-prompts")
+
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/unique.mdc"), "nothing is written when sources conflict")
+		assert.NoFileExists(t, filepath.Join(workDir, "Promptsfile.lock"))
+	})
+
+	t.Run("the first failing source in Promptsfile order is reported", func(t *testing.T) {
+		workDir := t.TempDir()
+		fetcher := setupParallelSources(t, workDir, map[string]string{
+			"github.com/org/a-prompts": "a.md",
+		})
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(strings.Join([]string{
+			"sources:",
+			"  - github.com/org/missing-1",
+			"  - github.com/org/a-prompts",
+			"  - github.com/org/missing-2",
+		}, "\n")+"\n"), 0644))
+
+		for attempt := 0; attempt < 5; attempt++ {
+			err := runParallelInstall(t, workDir, fetcher, 3)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "github.com/org/missing-1")
+		}
+	})
+}
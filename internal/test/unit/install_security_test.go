@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+// lockedFile returns the lock entry rendered from a source path
+func lockedFile(t *testing.T, workDir, sourcePath string) lock.File {
+	t.Helper()
+
+	lockData, err := lock.New(workDir).Read()
+	require.NoError(t, err)
+	for _, source := range lockData.Sources {
+		for _, file := range source.Files {
+			if file.SourcePath == sourcePath {
+				return file
+			}
+		}
+	}
+	t.Fatalf("%s not found in lock file", sourcePath)
+	return lock.File{}
+}
+
+func TestInstall_SecurityThreshold(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/metadata.yaml": "defaults:\n  security: medium\nfiles:\n  style.md:\n    security: low\n",
+		"prompts/style.md":      "# Style\n",
+		"prompts/refactor.md":   "# Refactor\n",
+		"prompts/deploy.md":     "---\nsecurity: high\n---\n# Deploy\n",
+	})
+
+	newWorkspace := func(t *testing.T) string {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nsecurity:\n  threshold: medium\n"), 0644))
+		return workDir
+	}
+
+	t.Run("strict mode fails above the threshold", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security level high exceeds the threshold medium")
+		assert.NotContains(t, err.Error(), "refactor.md", "medium is within the threshold")
+		assert.NoFileExists(t, filepath.Join(workDir, "Promptsfile.lock"))
+	})
+
+	t.Run("flag overrides the Promptsfile threshold", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true, SecurityThreshold: "high"}))
+	})
+
+	t.Run("lock records the resolved level of each file", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+		assert.Equal(t, "low", lockedFile(t, workDir, "prompts/style.md").Security, "metadata.yaml file override")
+		assert.Equal(t, "medium", lockedFile(t, workDir, "prompts/refactor.md").Security, "metadata.yaml default")
+		assert.Equal(t, "high", lockedFile(t, workDir, "prompts/deploy.md").Security, "front-matter wins")
+	})
+
+	t.Run("invalid threshold is rejected", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{SecurityThreshold: "extreme"})
+		assert.ErrorContains(t, err, "invalid security threshold")
+	})
+}
+
+func TestInstall_SecurityLevelIncrease(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/tool.md": "---\nsecurity: low\n---\n# Tool\n"})
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+		[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	// Drift checks resolve lock paths against the working directory
+	oldWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(workDir))
+	defer os.Chdir(oldWd)
+
+	verify := func() error {
+		return runInstall(t, workDir, cacheDir, workflow.InstallOptions{VerifyOnly: true})
+	}
+	require.NoError(t, verify())
+
+	commitPromptFiles(t, repo, map[string]string{"prompts/tool.md": "---\nsecurity: high\n---\n# Tool that runs commands\n"}, "Raise level")
+	update := workflow.InstallOptions{UpdateSources: []string{repo}}
+
+	t.Run("strict install refuses the increase", func(t *testing.T) {
+		opts := update
+		opts.StrictMode = true
+		err := runInstall(t, workDir, cacheDir, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security level increased from low to high")
+		assert.Equal(t, "low", lockedFile(t, workDir, "prompts/tool.md").Security)
+	})
+
+	t.Run("unapproved increase is recorded and fails verify", func(t *testing.T) {
+		require.NoError(t, runInstall(t, workDir, cacheDir, update))
+
+		file := lockedFile(t, workDir, "prompts/tool.md")
+		assert.Equal(t, "high", file.Security)
+		assert.Equal(t, "low", file.ApprovedSecurity)
+
+		err := verify()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security level increased without approval")
+	})
+
+	t.Run("approval clears the increase", func(t *testing.T) {
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{ApproveSecurity: true}))
+
+		file := lockedFile(t, workDir, "prompts/tool.md")
+		assert.Equal(t, "high", file.Security)
+		assert.Empty(t, file.ApprovedSecurity)
+		require.NoError(t, verify())
+	})
+}
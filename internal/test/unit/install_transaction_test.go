@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_Transaction(t *testing.T) {
+	setup := func(t *testing.T) (repo, workDir, cacheDir string) {
+		repo = createPromptRepo(t, map[string]string{"prompts/style.md": "# Style v1\n"})
+		workDir = t.TempDir()
+		cacheDir = t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+		return repo, workDir, cacheDir
+	}
+
+	t.Run("failed install restores the previous workspace", func(t *testing.T) {
+		repo, workDir, cacheDir := setup(t)
+		stylePath := filepath.Join(workDir, ".cursor/rules/_active/style.mdc")
+		extraPath := filepath.Join(workDir, ".cursor/rules/_active/extra.mdc")
+
+		lockBefore, err := os.ReadFile(filepath.Join(workDir, "Promptsfile.lock"))
+		require.NoError(t, err)
+
+		commitPromptFiles(t, repo, map[string]string{
+			"prompts/style.md": "# Style v2\n",
+			"prompts/extra.md": "# Extra\n",
+		}, "Update prompts")
+
+		// Writing the lock file is the last step, make it fail
+		blocker := filepath.Join(workDir, "Promptsfile.lock.tmp")
+		require.NoError(t, os.MkdirAll(filepath.Join(blocker, "busy"), 0755))
+
+		err = runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to write lock file")
+
+		content, err := os.ReadFile(stylePath)
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v1\n", string(content), "updated files are restored")
+		assert.NoFileExists(t, extraPath, "new files are removed")
+
+		lockAfter, err := os.ReadFile(filepath.Join(workDir, "Promptsfile.lock"))
+		require.NoError(t, err)
+		assert.Equal(t, string(lockBefore), string(lockAfter))
+		assert.NoDirExists(t, filepath.Join(workDir, ".prompt-sync-txn"))
+
+		// Once the problem is fixed the install goes through
+		require.NoError(t, os.RemoveAll(blocker))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}}))
+		content, err = os.ReadFile(stylePath)
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v2\n", string(content))
+		assert.FileExists(t, extraPath)
+	})
+
+	t.Run("failed install removes the directories it created", func(t *testing.T) {
+		repo, workDir, cacheDir := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+			"sources:\n  - "+repo+"#main\nadapters:\n  cursor:\n    enabled: true\n  windsurf:\n    enabled: true\n"), 0644))
+
+		blocker := filepath.Join(workDir, "Promptsfile.lock.tmp")
+		require.NoError(t, os.MkdirAll(filepath.Join(blocker, "busy"), 0755))
+
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to write lock file")
+		assert.NoDirExists(t, filepath.Join(workDir, ".windsurf"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"), "directories that held files before are kept")
+	})
+
+	t.Run("interrupted install is rolled back by the next install", func(t *testing.T) {
+		_, workDir, cacheDir := setup(t)
+		stylePath := filepath.Join(workDir, ".cursor/rules/_active/style.mdc")
+		strayPath := filepath.Join(workDir, ".cursor/rules/_active/stray.mdc")
+
+		// Simulate an install that died after replacing one file and adding another
+		txnDir := filepath.Join(workDir, ".prompt-sync-txn")
+		backup := filepath.Join(txnDir, "backup", "0")
+		require.NoError(t, os.MkdirAll(filepath.Dir(backup), 0755))
+		require.NoError(t, os.WriteFile(backup, []byte("# Style v1\n"), 0644))
+		journal, err := json.Marshal([]map[string]string{
+			{"path": stylePath, "backup": backup},
+			{"path": strayPath},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(txnDir, "journal.json"), journal, 0644))
+		require.NoError(t, os.WriteFile(stylePath, []byte("# half written"), 0644))
+		require.NoError(t, os.WriteFile(strayPath, []byte("# Stray\n"), 0644))
+
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{FrozenLockfile: true}))
+
+		content, err := os.ReadFile(stylePath)
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v1\n", string(content))
+		assert.NoFileExists(t, strayPath)
+		assert.NoDirExists(t, txnDir)
+	})
+
+	t.Run("concurrent installs do not undo each other", func(t *testing.T) {
+		repo, workDir, _ := setup(t)
+		stylePath := filepath.Join(workDir, ".cursor/rules/_active/style.mdc")
+		commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "# Style v2\n"}, "Update style")
+
+		installers := make([]*workflow.Installer, 2)
+		for idx := range installers {
+			installer, err := workflow.New(workflow.InstallOptions{
+				WorkspaceDir:  workDir,
+				CacheDir:      t.TempDir(),
+				AllowUnknown:  true,
+				UpdateSources: []string{repo},
+			})
+			require.NoError(t, err)
+			installers[idx] = installer
+		}
+
+		errs := make([]error, len(installers))
+		var wg sync.WaitGroup
+		for idx, installer := range installers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[idx] = installer.Execute()
+			}()
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.Contains(t, err.Error(), fmt.Sprintf("another install (pid %d) is in progress", os.Getpid()))
+		}
+		assert.GreaterOrEqual(t, succeeded, 1)
+
+		content, err := os.ReadFile(stylePath)
+		require.NoError(t, err)
+		assert.Equal(t, "# Style v2\n", string(content))
+		assert.NoDirExists(t, filepath.Join(workDir, ".prompt-sync-txn"))
+
+		gitignore, err := os.ReadFile(filepath.Join(workDir, ".gitignore"))
+		require.NoError(t, err)
+		assert.Contains(t, string(gitignore), ".prompt-sync-txn/\n")
+	})
+
+	t.Run("a running install is not rolled back", func(t *testing.T) {
+		_, workDir, cacheDir := setup(t)
+		stylePath := filepath.Join(workDir, ".cursor/rules/_active/style.mdc")
+
+		// The journal of an install that is still running
+		txnDir := filepath.Join(workDir, ".prompt-sync-txn")
+		require.NoError(t, os.MkdirAll(txnDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(txnDir, "owner"), []byte(strconv.Itoa(os.Getpid())), 0644))
+		journal, err := json.Marshal([]map[string]string{{"path": stylePath}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(txnDir, "journal.json"), journal, 0644))
+
+		err = runInstall(t, workDir, cacheDir, workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "another install")
+		assert.FileExists(t, stylePath)
+		assert.DirExists(t, txnDir)
+
+		// A directory without a live owner is recovered
+		require.NoError(t, os.WriteFile(filepath.Join(txnDir, "owner"), []byte("0"), 0644))
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+		assert.FileExists(t, stylePath)
+		assert.NoDirExists(t, txnDir)
+	})
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kovyrin/prompt-sync/internal/git"
 	"github.com/kovyrin/prompt-sync/internal/lock"
 	"github.com/kovyrin/prompt-sync/internal/workflow"
 )
@@ -87,9 +89,9 @@ adapters:
 		// Verify cleanup worked correctly
 		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/authentication.md"), "Old file should be removed")
 		assert.NoFileExists(t, filepath.Join(workDir, ".claude/commands/test-authentication.md"), "Old Claude file should be removed")
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.md"), "Common file should remain")
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.md"), "New file should exist")
-		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.md"), "New file should exist")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/common.mdc"), "Common file should remain")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/auth-patterns.mdc"), "New file should exist")
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/breaking-changes.mdc"), "New file should exist")
 	})
 
 	t.Run("handles multiple sources correctly", func(t *testing.T) {
@@ -240,6 +242,19 @@ func (m *MockGitFetcherForCleanup) CachedPath(url, ref string) (string, bool) {
 	return "", false
 }
 
+func (m *MockGitFetcherForCleanup) ResolveRemote(url, ref string) (*git.RemoteRef, error) {
+	return nil, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *MockGitFetcherForCleanup) CommitsBehind(url, from, to string) (int, error) {
+	return 0, fmt.Errorf("remote queries are not supported by the mock")
+}
+
+func (m *MockGitFetcherForCleanup) CheckoutCommit(url, commit string) (string, error) {
+	path, _, err := m.CloneOrUpdate(url, m.version)
+	return path, err
+}
+
 func (m *MockGitFetcherForCleanup) CloneOrUpdate(url, ref string) (string, string, error) {
 	tmpDir, err := os.MkdirTemp("", "mock-repo-*")
 	if err != nil {
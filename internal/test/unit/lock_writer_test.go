@@ -100,7 +100,7 @@ func TestLockWriter(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, lock)
 
-		assert.Equal(t, "1.0", lock.Version)
+		assert.Equal(t, "1.1", lock.Version)
 		assert.WithinDuration(t, time.Now().UTC(), lock.Generated, 5*time.Second)
 		assert.Len(t, lock.Sources, 1)
 
@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestPromptsfileSchema(t *testing.T) {
+	load := func(t *testing.T, promptsfile string) (*config.ExtendedConfig, error) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Promptsfile"), []byte(promptsfile), 0644))
+		return config.NewLoader(dir).Load()
+	}
+
+	t.Run("legacy strings and source objects are both accepted", func(t *testing.T) {
+		cfg, err := load(t, `version: 1
+sources:
+  - github.com/acme/legacy//go#v1.0.0
+  - name: acme
+    repo: github.com/acme/prompts
+    ref: main
+    subdirectory: /packs/
+    adapters:
+      exclude: [claude]
+    prefix: acme
+    vars:
+      team: platform
+`)
+		require.NoError(t, err)
+		require.Len(t, cfg.Sources, 2)
+		assert.Equal(t, config.SourceCfg{Repo: "github.com/acme/legacy", Subdirectory: "go", Ref: "v1.0.0"}, cfg.Sources[0])
+		assert.Equal(t, "github.com/acme/prompts//packs", cfg.Sources[1].URL())
+		assert.False(t, cfg.Sources[1].Adapters.Allows("claude"))
+		assert.True(t, cfg.Sources[1].Adapters.Allows("cursor"))
+		assert.Equal(t, "platform", cfg.Variables(cfg.Sources[1])["team"])
+
+		out, err := yaml.Marshal(cfg.Sources)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "- github.com/acme/legacy//go#v1.0.0\n", "plain sources keep the legacy form")
+		assert.Contains(t, string(out), "name: acme\n")
+	})
+
+	t.Run("invalid Promptsfiles are rejected", func(t *testing.T) {
+		_, err := load(t, "version: 2\nsources: []\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported Promptsfile version 2")
+
+		_, err = load(t, "sources:\n  - name: acme\n    ref: main\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `source "acme" has no repo`)
+	})
+
+	t.Run("rulesets select packs of named sources", func(t *testing.T) {
+		cfg, err := load(t, `version: 1
+sources:
+  - name: acme
+    repo: github.com/acme/prompts
+    ref: main
+  - github.com/acme/other
+rulesets:
+  - acme/go@v2.0.0
+  - acme/ruby
+  - shared/base
+`)
+		require.NoError(t, err)
+
+		lookup := func(name string) (config.Source, bool) {
+			if name == "shared" {
+				return config.Source{Name: "shared", Repo: "github.com/acme/shared", ClaudePrefix: "shared"}, true
+			}
+			return config.Source{}, false
+		}
+		selected, err := cfg.Selected(lookup)
+		require.NoError(t, err)
+
+		var specs []string
+		for _, source := range selected {
+			specs = append(specs, source.String())
+		}
+		assert.Equal(t, []string{
+			"github.com/acme/other",
+			"github.com/acme/prompts//go#v2.0.0",
+			"github.com/acme/prompts//ruby#main",
+			"github.com/acme/shared//base",
+		}, specs, "acme is only installed through its rulesets")
+		assert.Equal(t, "shared", selected[3].Prefix)
+
+		cfg.Rulesets = []string{"missing/go"}
+		_, err = cfg.Selected(lookup)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ruleset missing/go: unknown source "missing"`)
+
+		cfg.Rulesets = []string{"acme/../secrets"}
+		_, err = cfg.Selected(lookup)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pack must be a directory inside the source")
+	})
+}
+
+func TestInstall_NamedSources(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"go/prompts/metadata.yaml": "defaults:\n  template: true\n",
+		"go/prompts/style.md":      "Use gofmt for {{.team}}.\n",
+		"ruby/prompts/style.md":    "Use rubocop.\n",
+		"prompts/metadata.yaml":    "defaults:\n  template: true\n",
+		"prompts/review.md":        "Review for {{.team}}.\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(`version: 1
+sources:
+  - name: acme
+    repo: `+repo+`
+    ref: main
+    adapters:
+      exclude: [claude]
+    vars:
+      team: backend
+  - repo: `+repo+`
+    ref: main
+    adapters:
+      include: [claude]
+    prefix: team
+rulesets:
+  - acme/go
+vars:
+  team: platform
+adapters:
+  cursor:
+    enabled: true
+  claude:
+    enabled: true
+`), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	style, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+	require.NoError(t, err)
+	assert.Equal(t, "Use gofmt for backend.\n", string(style), "the ruleset installs the go pack with the source's vars")
+	assert.NoFileExists(t, filepath.Join(workDir, ".claude/commands/acme-style.md"), "claude is excluded for acme")
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/review.mdc"), "the second source only renders for claude")
+
+	review, err := os.ReadFile(filepath.Join(workDir, ".claude/commands/team-review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Review for platform.\n", string(review))
+
+	lockData, err := lock.New(workDir).Read()
+	require.NoError(t, err)
+	var urls []string
+	for _, source := range lockData.Sources {
+		urls = append(urls, source.URL)
+	}
+	assert.ElementsMatch(t, []string{repo, repo + "//go"}, urls)
+
+	t.Run("locked packs are verified", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{VerifyOnly: true}))
+	})
+}
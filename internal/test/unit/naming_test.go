@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_NamingStrategies(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/security/auth.md": "# Security auth\n",
+		"prompts/style/auth.md":    "# Style auth\n",
+	})
+
+	newWorkspace := func(t *testing.T, adapters string) string {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\nadapters:\n"+adapters), 0644))
+		return workDir
+	}
+
+	t.Run("nested keeps the directory structure", func(t *testing.T) {
+		workDir := newWorkspace(t, "  cursor:\n    enabled: true\n    naming: nested\n  claude:\n    enabled: true\n    prefix: acme\n    naming: nested\n")
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true}),
+			"files sharing a base name do not conflict")
+
+		source := filepath.Base(repo)
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active", source, "security/auth.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active", source, "style/auth.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme/security/auth.md"))
+		assert.Equal(t, ".claude/commands/acme/style/auth.md", lockedFile(t, workDir, "prompts/style/auth.md").Path)
+
+		gitignore, err := os.ReadFile(filepath.Join(workDir, ".gitignore"))
+		require.NoError(t, err)
+		assert.Contains(t, string(gitignore), ".claude/commands/acme/")
+	})
+
+	t.Run("path derives flat names from the path", func(t *testing.T) {
+		workDir := newWorkspace(t, "  cursor:\n    enabled: true\n    naming: path\n  claude:\n    enabled: true\n    prefix: acme\n    naming: path\n")
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true}))
+
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/security-auth.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style-auth.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-security-auth.md"))
+	})
+
+	t.Run("invalid strategies are rejected", func(t *testing.T) {
+		workDir := newWorkspace(t, "  cursor:\n    enabled: true\n    naming: deep\n")
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{})
+		assert.ErrorContains(t, err, `adapter cursor: invalid naming "deep"`)
+	})
+}
+
+func TestInstall_NestedCleanup(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/security/auth.md": "# Security auth\n",
+		"prompts/style/auth.md":    "# Style auth\n",
+	})
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+		[]byte("sources:\n  - "+repo+"#main\nadapters:\n  cursor:\n    enabled: true\n    naming: nested\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	runGitCmd(t, repo, "rm", "-q", "prompts/security/auth.md")
+	runGitCmd(t, repo, "commit", "-m", "Remove security auth")
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}}))
+
+	sourceDir := filepath.Join(workDir, ".cursor/rules/_active", filepath.Base(repo))
+	assert.NoDirExists(t, filepath.Join(sourceDir, "security"), "emptied directories are removed")
+	assert.FileExists(t, filepath.Join(sourceDir, "style/auth.mdc"))
+}
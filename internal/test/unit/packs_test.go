@@ -0,0 +1,238 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/pack"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestDiscoverPacks(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+	write("packs/ruby/pack.yaml", "name: ruby-style\ndescription: Ruby conventions\n")
+	write("packs/git-workflow/pack.yaml", "description: Commit conventions\n")
+	write("packs/git-workflow/nested/pack.yaml", "name: ignored\n")
+	write(".github/pack.yaml", "name: hidden\n")
+	write("go/prompts/style.md", "Use gofmt.\n")
+
+	packs, err := pack.Discover(root)
+	require.NoError(t, err)
+	require.Len(t, packs, 2)
+	assert.Equal(t, "git-workflow", packs[0].Name, "the directory names packs without a name")
+	assert.Equal(t, "packs/git-workflow", packs[0].Dir)
+	assert.Equal(t, "ruby-style", packs[1].Name)
+	assert.Equal(t, "Ruby conventions", packs[1].Description)
+
+	t.Run("packs resolve by name, then by path", func(t *testing.T) {
+		dir, err := pack.Resolve(root, "ruby-style")
+		require.NoError(t, err)
+		assert.Equal(t, "packs/ruby", dir)
+
+		dir, err = pack.Resolve(root, "go")
+		require.NoError(t, err)
+		assert.Equal(t, "go", dir)
+
+		_, err = pack.Resolve(root, "python")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `pack "python" not found (available: git-workflow, ruby-style)`)
+	})
+
+	t.Run("manifests are validated", func(t *testing.T) {
+		dir := t.TempDir()
+		for content, want := range map[string]string{
+			"version: latest\n":                `invalid version "latest"`,
+			"min_version: soon\n":              `invalid min_version "soon"`,
+			"dependencies: [/base]\n":          `invalid dependency "/base"`,
+			"dependencies: [shared/base@^x]\n": `invalid dependency "shared/base@^x"`,
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, pack.ManifestFile), []byte(content), 0644))
+			_, err := pack.LoadManifest(dir)
+			require.Error(t, err, content)
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("pack names are unique", func(t *testing.T) {
+		write("other/pack.yaml", "name: ruby-style\n")
+		_, err := pack.Discover(root)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `pack "ruby-style" is declared in both other and packs/ruby`)
+	})
+}
+
+func TestInstall_Packs(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"packs/ruby/pack.yaml":                  "name: ruby-style\n",
+		"packs/ruby/prompts/style.md":           "Use rubocop.\n",
+		"packs/git-workflow/pack.yaml":          "description: Commit conventions\n",
+		"packs/git-workflow/prompts/commits.md": "Write imperative subjects.\n",
+		"packs/unused/pack.yaml":                "name: unused\n",
+		"packs/unused/prompts/unused.md":        "Unused.\n",
+	})
+
+	workDir := t.TempDir()
+	writeRulesets := func(rulesets string) {
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(`version: 1
+sources:
+  - name: my-org
+    repo: `+repo+`
+    ref: main
+rulesets:
+`+rulesets), 0644))
+	}
+	writeRulesets("  - my-org/ruby-style\n  - my-org/git-workflow\n")
+	cacheDir := t.TempDir()
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+	assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/commits.mdc"))
+	assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/unused.mdc"), "packs are only installed when selected")
+
+	lockData, err := lock.New(workDir).Read()
+	require.NoError(t, err)
+	files := make(map[string][]string)
+	for _, source := range lockData.Sources {
+		for _, file := range source.Files {
+			files[source.URL] = append(files[source.URL], file.SourcePath)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		repo + "//ruby-style":   {"prompts/style.md"},
+		repo + "//git-workflow": {"prompts/commits.md"},
+	}, files, "each pack is locked on its own")
+
+	t.Run("packs are removed by their ruleset", func(t *testing.T) {
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+		require.NoError(t, runRemoveCommand([]string{"my-org/ruby-style"}))
+
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/commits.mdc"))
+
+		cfg, err := config.NewLoader(workDir).Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"my-org/git-workflow"}, cfg.Rulesets)
+
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		require.Len(t, lockData.Sources, 1)
+		assert.Equal(t, repo+"//git-workflow", lockData.Sources[0].URL)
+	})
+
+	t.Run("unknown packs fail the install", func(t *testing.T) {
+		writeRulesets("  - my-org/python\n")
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `ruleset my-org/python: pack "python" not found (available: git-workflow, ruby-style, unused)`)
+	})
+}
+
+func TestInstall_PackDependencies(t *testing.T) {
+	shared := createPromptRepo(t, map[string]string{
+		"base/pack.yaml":          "name: base\nversion: 2.1.0\n",
+		"base/prompts/base.md":    "Be kind.\n",
+		"legacy/pack.yaml":        "name: legacy\nversion: 1.0.0\n",
+		"legacy/prompts/old.md":   "Old rules.\n",
+		"cycle-a/pack.yaml":       "name: cycle-a\ndependencies: [cycle-b]\n",
+		"cycle-a/prompts/a.md":    "A\n",
+		"cycle-b/pack.yaml":       "name: cycle-b\ndependencies: [cycle-c]\n",
+		"cycle-b/prompts/b.md":    "B\n",
+		"cycle-c/pack.yaml":       "name: cycle-c\ndependencies: [cycle-a]\n",
+		"cycle-c/prompts/c.md":    "C\n",
+		"future/pack.yaml":        "name: future\nmin_version: 99.0.0\n",
+		"future/prompts/later.md": "Later.\n",
+	})
+	repo := createPromptRepo(t, map[string]string{
+		"packs/ruby/pack.yaml":                  "name: ruby-style\nversion: 1.2.0\nlicense: MIT\ndependencies:\n  - git-workflow@^1.0\n  - shared/base@^2\n",
+		"packs/ruby/prompts/style.md":           "Use rubocop.\n",
+		"packs/git-workflow/pack.yaml":          "name: git-workflow\nversion: 1.1.0\nadapters: [cursor]\ndependencies: [shared/base@>=2.1]\n",
+		"packs/git-workflow/prompts/commits.md": "Write imperative subjects.\n",
+		"packs/upgrade/pack.yaml":               "name: upgrade\ndependencies: [shared/legacy@^2.0]\n",
+		"packs/upgrade/prompts/upgrade.md":      "Upgrade.\n",
+	})
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("sources:\n  - name: shared\n    repo: "+shared+"\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	install := func(t *testing.T, rulesets string, opts workflow.InstallOptions) (string, error) {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(`version: 1
+sources:
+  - name: my-org
+    repo: `+repo+`
+    ref: main
+rulesets:
+`+rulesets+`adapters:
+  cursor:
+    enabled: true
+  claude:
+    enabled: true
+    prefix: acme
+`), 0644))
+		return workDir, runInstall(t, workDir, t.TempDir(), opts)
+	}
+
+	t.Run("dependencies are installed transitively and locked", func(t *testing.T) {
+		workDir, err := install(t, "  - my-org/ruby-style\n", workflow.InstallOptions{})
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/style.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/commits.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/base.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-style.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".claude/commands/acme-commits.md"), "git-workflow only supports cursor")
+
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		sources := make(map[string]lock.Source)
+		for _, source := range lockData.Sources {
+			sources[source.URL] = source
+		}
+		require.Len(t, sources, 3)
+		assert.Equal(t, "1.2.0", sources[repo+"//ruby-style"].PackVersion)
+		assert.Equal(t, []lock.Dependency{
+			{Pack: "git-workflow", Range: "^1.0", Version: "1.1.0", URL: repo + "//git-workflow"},
+			{Pack: "shared/base", Range: "^2", Version: "2.1.0", URL: shared + "//base"},
+		}, sources[repo+"//ruby-style"].Dependencies)
+		assert.Equal(t, []lock.Dependency{
+			{Pack: "shared/base", Range: ">=2.1", Version: "2.1.0", URL: shared + "//base"},
+		}, sources[repo+"//git-workflow"].Dependencies)
+		assert.Equal(t, "main", sources[repo+"//git-workflow"].Ref, "packs of the same source share its ref")
+
+		oldWd, _ := os.Getwd()
+		require.NoError(t, os.Chdir(workDir))
+		defer os.Chdir(oldWd)
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{FrozenLockfile: true}), "locked dependencies are in sync")
+	})
+
+	t.Run("version conflicts fail the install", func(t *testing.T) {
+		_, err := install(t, "  - my-org/upgrade\n", workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "version conflict: upgrade requires legacy ^2.0, but legacy is 1.0.0")
+	})
+
+	t.Run("cycles fail the install", func(t *testing.T) {
+		_, err := install(t, "  - shared/cycle-a\n", workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dependency cycle: cycle-a -> cycle-b -> cycle-c -> cycle-a")
+	})
+
+	t.Run("packs may require a newer prompt-sync", func(t *testing.T) {
+		_, err := install(t, "  - shared/future\n", workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pack future requires prompt-sync 99.0.0 or newer")
+	})
+}
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/policy"
+	"github.com/kovyrin/prompt-sync/internal/security"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p, err := policy.Load(writePolicy(t, `allowed_sources:
+  - github.com:acme/*
+require_pinning:
+  - github.com:acme/prod-*
+max_security_level:
+  project: medium
+forbidden_adapters: [claude]
+allow_unknown: false
+min_version: 0.2.0
+`))
+	require.NoError(t, err)
+
+	t.Run("compliant install has no issues", func(t *testing.T) {
+		issues := p.Check(policy.Install{
+			Sources:     []policy.Source{{URL: "github.com/acme/prod-rules", Ref: "v1.2.0", Scope: "project"}},
+			Files:       []policy.File{{Path: "a.md", Scope: "project", SecurityLevel: security.LevelMedium}},
+			Adapters:    []string{"cursor"},
+			ToolVersion: "0.2.1",
+		})
+		assert.Empty(t, issues)
+	})
+
+	t.Run("packs follow the rules of their repository", func(t *testing.T) {
+		exact, err := policy.Load(writePolicy(t, "allowed_sources:\n  - github.com:acme/rules\n"))
+		require.NoError(t, err)
+		issues := exact.Check(policy.Install{
+			Sources: []policy.Source{{URL: "github.com/acme/rules//ruby-style", Scope: "project"}},
+		})
+		assert.Empty(t, issues)
+	})
+
+	t.Run("every rule reports a policy issue", func(t *testing.T) {
+		issues := p.Check(policy.Install{
+			Sources: []policy.Source{
+				{URL: "github.com/evil/prompts", Scope: "project"},
+				{URL: "git@github.com:acme/prod-rules.git", Ref: "main", Scope: "project"},
+			},
+			Files:        []policy.File{{Path: ".cursor/rules/_active/deploy.md", SourceURL: "github.com/acme/ops", Scope: "project", SecurityLevel: security.LevelHigh}},
+			Adapters:     []string{"claude", "cursor"},
+			AllowUnknown: true,
+			ToolVersion:  "0.1.0",
+		})
+
+		details := make(map[string]string)
+		for _, issue := range issues {
+			assert.Equal(t, "policy", issue.Type)
+			assert.True(t, issue.IsCritical)
+			details[issue.Path] = issue.Details
+		}
+		assert.Len(t, issues, 6)
+		assert.Contains(t, details["github.com/evil/prompts"], "outside the allowed")
+		assert.Contains(t, details["git@github.com:acme/prod-rules.git"], "must be pinned")
+		assert.Contains(t, details[".cursor/rules/_active/deploy.md"], "security level high exceeds the medium")
+		assert.Contains(t, details["claude"], "forbidden")
+		assert.Contains(t, details["--allow-unknown"], "not permitted")
+		assert.Contains(t, details["prompt-sync"], "older than the required 0.2.0")
+	})
+
+	t.Run("nil policy allows everything", func(t *testing.T) {
+		var none *policy.Policy
+		assert.Empty(t, none.Check(policy.Install{AllowUnknown: true}))
+	})
+
+	t.Run("invalid levels are rejected", func(t *testing.T) {
+		_, err := policy.Load(writePolicy(t, "max_security_level:\n  project: extreme\n"))
+		assert.ErrorContains(t, err, "max_security_level.project")
+	})
+}
+
+func TestPolicy_Path(t *testing.T) {
+	userDir := t.TempDir()
+	userConfig := filepath.Join(userDir, "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("policy: org-policy.yaml\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+	t.Setenv(policy.EnvVar, "")
+
+	cfg, err := config.Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(userDir, "org-policy.yaml"), policy.Path(cfg), "relative to the user config")
+
+	t.Setenv(policy.EnvVar, "/etc/prompt-sync/policy.yaml")
+	assert.Equal(t, "/etc/prompt-sync/policy.yaml", policy.Path(cfg), "environment wins")
+}
+
+func TestInstall_EnforcesPolicy(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/deploy.md": "---\nsecurity: high\n---\n# Deploy\n",
+	})
+	t.Setenv(policy.EnvVar, writePolicy(t, "max_security_level:\n  project: medium\n"))
+
+	newWorkspace := func(t *testing.T) string {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#main\n"), 0644))
+		return workDir
+	}
+
+	t.Run("strict mode fails before writing", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "policy violations")
+		assert.Contains(t, err.Error(), "security level high exceeds the medium allowed for project sources")
+		assert.NoFileExists(t, filepath.Join(workDir, ".cursor/rules/_active/deploy.mdc"))
+		assert.NoFileExists(t, filepath.Join(workDir, "Promptsfile.lock"))
+	})
+
+	t.Run("violations are warnings otherwise", func(t *testing.T) {
+		workDir := newWorkspace(t)
+
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/deploy.mdc"))
+	})
+}
@@ -25,10 +25,10 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create initial Promptsfile with multiple sources
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts1",
-				"github.com/org/prompts2",
-				"github.com/org/prompts3",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts1"),
+				config.ParseSource("github.com/org/prompts2"),
+				config.ParseSource("github.com/org/prompts3"),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -42,9 +42,9 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Verify the source was removed
 		cfg := readPromptsfileForRemove(t, tmpDir)
-		assert.NotContains(t, cfg.Sources, "github.com/org/prompts2")
-		assert.Contains(t, cfg.Sources, "github.com/org/prompts1")
-		assert.Contains(t, cfg.Sources, "github.com/org/prompts3")
+		assert.NotContains(t, cfg.Sources, config.ParseSource("github.com/org/prompts2"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/prompts1"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/prompts3"))
 		assert.Len(t, cfg.Sources, 2)
 	})
 
@@ -56,9 +56,9 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile with versioned source
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts#v1.0.0",
-				"github.com/org/other-prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts#v1.0.0"),
+				config.ParseSource("github.com/org/other-prompts"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -70,8 +70,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Verify it was removed
 		cfg := readPromptsfileForRemove(t, tmpDir)
-		assert.NotContains(t, cfg.Sources, "github.com/org/prompts#v1.0.0")
-		assert.Contains(t, cfg.Sources, "github.com/org/other-prompts")
+		assert.NotContains(t, cfg.Sources, config.ParseSource("github.com/org/prompts#v1.0.0"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/other-prompts"))
 	})
 
 	t.Run("handling non-existent prompts gracefully", func(t *testing.T) {
@@ -82,8 +82,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -97,7 +97,7 @@ func TestRemoveCommand(t *testing.T) {
 		// Verify nothing was changed
 		cfg := readPromptsfileForRemove(t, tmpDir)
 		assert.Len(t, cfg.Sources, 1)
-		assert.Equal(t, "github.com/org/prompts", cfg.Sources[0])
+		assert.Equal(t, "github.com/org/prompts", cfg.Sources[0].String())
 	})
 
 	t.Run("cleaning up rendered files", func(t *testing.T) {
@@ -108,8 +108,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts"),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -161,9 +161,9 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile with multiple sources
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts1",
-				"github.com/org/prompts2",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts1"),
+				config.ParseSource("github.com/org/prompts2"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -218,8 +218,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile with one source
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -242,8 +242,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile with overlay
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts"),
 			},
 			Overlays: []config.Overlay{
 				{
@@ -273,9 +273,9 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"github.com/org/prompts#v1.0.0",
-				"github.com/org/prompts-utils",
+			Sources: []config.SourceCfg{
+				config.ParseSource("github.com/org/prompts#v1.0.0"),
+				config.ParseSource("github.com/org/prompts-utils"),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -287,8 +287,8 @@ func TestRemoveCommand(t *testing.T) {
 
 		// Verify only the exact match was removed
 		cfg := readPromptsfileForRemove(t, tmpDir)
-		assert.NotContains(t, cfg.Sources, "github.com/org/prompts#v1.0.0")
-		assert.Contains(t, cfg.Sources, "github.com/org/prompts-utils")
+		assert.NotContains(t, cfg.Sources, config.ParseSource("github.com/org/prompts#v1.0.0"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("github.com/org/prompts-utils"))
 	})
 }
 
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/semver"
+)
+
+func TestSemverConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{"^1.2", []string{"v1.2.0", "1.2.5", "v1.9.0"}, []string{"v1.1.9", "v2.0.0", "v1.3.0-rc.1"}},
+		{"^0.2.3", []string{"v0.2.3", "v0.2.9"}, []string{"v0.3.0", "v0.2.2"}},
+		{"^0.0.3", []string{"v0.0.3"}, []string{"v0.0.4"}},
+		{"~2.0.3", []string{"v2.0.3", "v2.0.10"}, []string{"v2.1.0", "v2.0.2"}},
+		{"~1", []string{"v1.0.0", "v1.5.2"}, []string{"v2.0.0"}},
+		{">=1.0 <2.0", []string{"v1.0.0", "v1.9.9"}, []string{"v0.9.0", "v2.0.0"}},
+		{">= 1.0, < 2.0", []string{"v1.4.0"}, []string{"v2.0.0"}},
+		{"=1.2.3", []string{"v1.2.3"}, []string{"v1.2.4"}},
+		{"^2.0.0-beta.1", []string{"v2.0.0-beta.2", "v2.0.0"}, []string{"v2.1.0-beta.1", "v3.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := semver.ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+
+			for _, raw := range tt.matches {
+				v, err := semver.Parse(raw)
+				require.NoError(t, err)
+				assert.True(t, c.Check(v), "%s should satisfy %s", raw, tt.constraint)
+			}
+			for _, raw := range tt.rejects {
+				v, err := semver.Parse(raw)
+				require.NoError(t, err)
+				assert.False(t, c.Check(v), "%s should not satisfy %s", raw, tt.constraint)
+			}
+		})
+	}
+}
+
+func TestSemverConstraint_IsConstraint(t *testing.T) {
+	for _, ref := range []string{"^1.2", "~2.0.3", ">=1.0 <2.0", "<3", "=1.0.0"} {
+		assert.True(t, semver.IsConstraint(ref), ref)
+	}
+	for _, ref := range []string{"", "main", "v1.2.3", "1.2.3", "abc1234", "^main", ">=x"} {
+		assert.False(t, semver.IsConstraint(ref), ref)
+	}
+}
+
+func TestSemverConstraint_Best(t *testing.T) {
+	versions := semver.ParseAll([]string{"v1.0.0", "v1.2.0", "v1.3.1", "v2.0.0", "v1.4.0-rc.1", "latest"})
+
+	c, err := semver.ParseConstraint("^1.2")
+	require.NoError(t, err)
+	best, ok := c.Best(versions)
+	require.True(t, ok)
+	assert.Equal(t, "v1.3.1", best.Original)
+
+	c, err = semver.ParseConstraint(">=3.0")
+	require.NoError(t, err)
+	_, ok = c.Best(versions)
+	assert.False(t, ok)
+}
+
+func TestSemverVersion_Compare(t *testing.T) {
+	tests := []struct {
+		lower, higher string
+	}{
+		{"1.0.0", "1.0.1"},
+		{"1.0.0-rc.1", "1.0.0"},
+		{"1.0.0-rc.2", "1.0.0-rc.10"},
+		{"1.0.0-alpha", "1.0.0-alpha.1"},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta"},
+		{"1.0.0-alpha.beta", "1.0.0-beta"},
+		{"1.0.0-beta.11", "1.0.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lower+" < "+tt.higher, func(t *testing.T) {
+			lower, err := semver.Parse(tt.lower)
+			require.NoError(t, err)
+			higher, err := semver.Parse(tt.higher)
+			require.NoError(t, err)
+
+			assert.Equal(t, -1, lower.Compare(higher))
+			assert.Equal(t, 1, higher.Compare(lower))
+			assert.Equal(t, 0, lower.Compare(lower))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestRoute(t *testing.T) {
+	meta := map[string]interface{}{"kind": "rule", "targets": []interface{}{"cursor", "claude.cmd"}}
+
+	routing := adapter.Route(meta, "cursor")
+	assert.True(t, routing.Rendered)
+	assert.Equal(t, "rule", routing.Kind)
+	assert.Equal(t, "cursor", routing.Target)
+
+	routing = adapter.Route(meta, "claude")
+	assert.True(t, routing.Rendered)
+	assert.Equal(t, "command", routing.Kind, "sub-targets override the kind")
+
+	routing = adapter.Route(meta, "copilot")
+	assert.False(t, routing.Rendered)
+	assert.Equal(t, "not in targets: cursor, claude.cmd", routing.Reason)
+
+	routing = adapter.Route(map[string]interface{}{}, "copilot")
+	assert.True(t, routing.Rendered)
+	assert.Empty(t, routing.Target)
+}
+
+func TestInstall_Targets(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/prd.md":   "---\nkind: rule\ntargets: [cursor, claude.cmd]\n---\n# PRD\n",
+		"prompts/style.md": "# Style\n",
+	})
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\n"+
+			"adapters:\n  cursor:\n    enabled: true\n  claude:\n    enabled: true\n    prefix: acme\n  copilot:\n    enabled: true\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	t.Run("only listed adapters render the prompt", func(t *testing.T) {
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/prd.mdc"))
+		assert.FileExists(t, filepath.Join(workDir, ".claude/commands/acme-prd.md"), "claude.cmd renders a command")
+		assert.NoFileExists(t, filepath.Join(workDir, "CLAUDE.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".github/instructions/prd.instructions.md"))
+
+		assert.FileExists(t, filepath.Join(workDir, ".github/instructions/style.instructions.md"), "prompts without targets go everywhere")
+	})
+
+	t.Run("the lock records routing decisions", func(t *testing.T) {
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		require.Len(t, lockData.Sources, 1)
+		source := lockData.Sources[0]
+
+		targets := make(map[string]string)
+		for _, file := range source.Files {
+			targets[file.Path] = file.Target
+		}
+		assert.Equal(t, "claude.cmd", targets[".claude/commands/acme-prd.md"])
+		assert.Equal(t, "cursor", targets[".cursor/rules/_active/prd.mdc"])
+		assert.Empty(t, targets[".cursor/rules/_active/style.mdc"])
+
+		assert.Equal(t, []lock.SkippedFile{
+			{SourcePath: "prompts/prd.md", Adapter: "copilot", Reason: "not in targets: cursor, claude.cmd"},
+		}, source.Skipped)
+	})
+
+	t.Run("list --files explains the routing", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runListCommand(workDir, []string{"--files"}, output))
+
+		assert.Contains(t, output.String(), ".claude/commands/acme-prd.md (targets: claude.cmd)")
+		assert.Contains(t, output.String(), "prompts/prd.md not rendered for copilot (not in targets: cursor, claude.cmd)")
+	})
+}
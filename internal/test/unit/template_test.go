@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]interface{}{"project": "billing", "languages": []interface{}{"go", "sql"}}
+
+	t.Run("variables and adapter conditionals are substituted", func(t *testing.T) {
+		body := "# {{.project}}\n{{if eq adapter \"claude\"}}Claude only\n{{else}}Others\n{{end}}{{range .languages}}- {{.}}\n{{end}}"
+		rendered, _, err := adapter.RenderTemplate("prompts/a.md", []byte(body), vars, "claude", true)
+		require.NoError(t, err)
+		assert.Equal(t, "# billing\nClaude only\n- go\n- sql\n", string(rendered))
+
+		rendered, _, err = adapter.RenderTemplate("prompts/a.md", []byte(body), vars, "cursor", true)
+		require.NoError(t, err)
+		assert.Equal(t, "# billing\nOthers\n- go\n- sql\n", string(rendered))
+	})
+
+	t.Run("bodies without actions are returned as is", func(t *testing.T) {
+		rendered, _, err := adapter.RenderTemplate("prompts/a.md", []byte("Run $ARGUMENTS\n"), nil, "cursor", true)
+		require.NoError(t, err)
+		assert.Equal(t, "Run $ARGUMENTS\n", string(rendered))
+	})
+
+	t.Run("undefined variables render empty unless strict", func(t *testing.T) {
+		rendered, warnings, err := adapter.RenderTemplate("prompts/a.md", []byte("Test with `{{.test_command}}`\n"), vars, "cursor", false)
+		require.NoError(t, err)
+		assert.Equal(t, "Test with ``\n", string(rendered))
+		assert.Equal(t, []string{`prompts/a.md: undefined template variable "test_command" rendered empty for cursor`}, warnings)
+
+		_, _, err = adapter.RenderTemplate("prompts/a.md", []byte("Test with `{{.test_command}}`\n"), vars, "cursor", true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `prompts/a.md: undefined template variable "test_command"`)
+	})
+
+	t.Run("warnings are collected on the pack", func(t *testing.T) {
+		pack := adapter.PromptPack{Vars: vars, Warnings: &adapter.Warnings{}}
+		prompt := adapter.Prompt{
+			SourcePath: "prompts/a.md",
+			Meta:       map[string]interface{}{"template": true},
+			Content:    []byte("Run {{.test_command}}\n"),
+			Body:       []byte("Run {{.test_command}}\n"),
+		}
+		prompt, err := adapter.ApplyTemplate(pack, prompt, "cursor")
+		require.NoError(t, err)
+		assert.Equal(t, "Run \n", string(prompt.Content))
+		assert.Equal(t, []string{`prompts/a.md: undefined template variable "test_command" rendered empty for cursor`}, pack.Warnings.Messages())
+	})
+
+	t.Run("fields inside range are not variables", func(t *testing.T) {
+		items := map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": "a"}}}
+		rendered, _, err := adapter.RenderTemplate("prompts/a.md", []byte("{{range .items}}{{.name}}{{end}}"), items, "cursor", true)
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(rendered))
+	})
+}
+
+func TestInstall_TemplateVars(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/metadata.yaml": "defaults:\n  template: true\nvars:\n  language: ruby\n  test_command: rake test\n",
+		"prompts/project.md":    "# {{.project}} ({{.language}})\nRun `{{.test_command}}`.\n{{if eq adapter \"claude\"}}Use subagents.\n{{end}}",
+		"prompts/raw.md":        "---\ntemplate: false\n---\nKeep {{.literal}}\n",
+	})
+
+	install := func(t *testing.T, promptsfile, local string, opts workflow.InstallOptions) (string, error) {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(promptsfile), 0644))
+		if local != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile.local"), []byte(local), 0644))
+		}
+		return workDir, runInstall(t, workDir, t.TempDir(), opts)
+	}
+	adapters := "adapters:\n  cursor:\n    enabled: true\n  claude:\n    enabled: true\n    prefix: acme\n"
+
+	t.Run("Promptsfile.local overrides the Promptsfile, which overrides pack defaults", func(t *testing.T) {
+		workDir, err := install(t,
+			"sources:\n  - "+repo+"#main\nvars:\n  project: billing\n  language: go\n  test_command: make test\n"+adapters,
+			"vars:\n  test_command: go test ./...\n",
+			workflow.InstallOptions{StrictMode: true})
+		require.NoError(t, err)
+
+		cursorRule, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/project.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "# billing (go)\nRun `go test ./...`.\n", string(cursorRule))
+
+		claudeCommand, err := os.ReadFile(filepath.Join(workDir, ".claude/commands/acme-project.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# billing (go)\nRun `go test ./...`.\nUse subagents.\n", string(claudeCommand))
+
+		cfg, err := config.NewLoader(workDir).Load()
+		require.NoError(t, err)
+		assert.Equal(t, "make test", cfg.Vars["test_command"], "Promptsfile.local is kept out of the Promptsfile")
+		assert.Equal(t, "go test ./...", cfg.Variables(config.SourceCfg{})["test_command"])
+
+		raw, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/raw.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "Keep {{.literal}}\n", string(raw), "template: false opts out")
+
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		hashes := map[string]string{}
+		for _, file := range lockData.Sources[0].Files {
+			hashes[file.Path] = file.Hash
+		}
+		hash, err := lock.New(workDir).CalculateFileHash(filepath.Join(workDir, ".cursor/rules/_active/project.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, hash, hashes[".cursor/rules/_active/project.mdc"], "the lock hashes the substituted output")
+	})
+
+	t.Run("undefined variables fail in strict mode", func(t *testing.T) {
+		_, err := install(t, "sources:\n  - "+repo+"#main\n"+adapters, "", workflow.InstallOptions{StrictMode: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `undefined template variable "project"`)
+
+		workDir, err := install(t, "sources:\n  - "+repo+"#main\n"+adapters, "", workflow.InstallOptions{})
+		require.NoError(t, err)
+		cursorRule, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/project.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "#  (ruby)\nRun `rake test`.\n", string(cursorRule))
+	})
+}
+
+func TestInstall_TemplatingIsOptIn(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/ci.md":      "Use `${{ secrets.GITHUB_TOKEN }}` in {{.workflow}} steps.\n",
+		"prompts/adapter.md": "---\ntemplate: true\n---\n{{adapter}} rule for {{.workflow}}\n",
+	})
+	promptsfile := "sources:\n  - " + repo + "#main\nvars:\n  workflow: release\n"
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(promptsfile), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{StrictMode: true}))
+
+	ci, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/ci.mdc"))
+	require.NoError(t, err)
+	assert.Equal(t, "Use `${{ secrets.GITHUB_TOKEN }}` in {{.workflow}} steps.\n", string(ci), "vars do not make prompts templates")
+
+	rule, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/adapter.mdc"))
+	require.NoError(t, err)
+	assert.Equal(t, "cursor rule for release\n", string(rule), "template: true opts in")
+
+	t.Run("invalid templates are installed unchanged unless strict", func(t *testing.T) {
+		broken := createPromptRepo(t, map[string]string{
+			"prompts/metadata.yaml": "defaults:\n  template: true\n",
+			"prompts/ci.md":         "Use `${{ secrets.GITHUB_TOKEN }}`.\n",
+		})
+
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte("sources:\n  - "+broken+"#main\n"), 0644))
+		require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+		ci, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/ci.mdc"))
+		require.NoError(t, err)
+		assert.Equal(t, "Use `${{ secrets.GITHUB_TOKEN }}`.\n", string(ci))
+
+		strictDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(strictDir, "Promptsfile"), []byte("sources:\n  - "+broken+"#main\n"), 0644))
+		err = runInstall(t, strictDir, t.TempDir(), workflow.InstallOptions{StrictMode: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `function "secrets" not defined`)
+	})
+}
@@ -1,10 +1,13 @@
 package unit
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/kovyrin/prompt-sync/internal/config"
 	"github.com/kovyrin/prompt-sync/internal/security"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
 )
 
 func TestEnsureTrusted(t *testing.T) {
@@ -49,3 +52,143 @@ func TestEnsureTrusted(t *testing.T) {
 		}
 	})
 }
+
+func TestTrustedSources_LayeredConfig(t *testing.T) {
+	projectDir := t.TempDir()
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	writeFile(t, userConfig, `sources:
+  - name: acme
+    repo: github.com:acme/*
+`)
+	// Plain install entries in the Promptsfile do not grant trust
+	writeFile(t, filepath.Join(projectDir, "Promptsfile"), `sources:
+  - github.com/evil/prompts#main
+`)
+	writeFile(t, filepath.Join(projectDir, "Promptsfile.local"), `trusted_sources:
+  - name: personal
+    repo: git@github.com:me/my-prompts.git
+`)
+
+	trusted, err := security.LoadTrustedSources(projectDir)
+	if err != nil {
+		t.Fatalf("LoadTrustedSources returned error: %v", err)
+	}
+
+	t.Run("reports the file that granted trust", func(t *testing.T) {
+		source, ok := trusted.TrustedBy("github.com/acme/style-prompts")
+		if !ok {
+			t.Fatalf("expected namespace wildcard to trust repo")
+		}
+		if source.File != userConfig {
+			t.Errorf("trusted by %s, want %s", source.File, userConfig)
+		}
+
+		source, ok = trusted.TrustedBy("https://github.com/me/my-prompts.git")
+		if !ok {
+			t.Fatalf("expected Promptsfile.local to trust repo")
+		}
+		if want := filepath.Join(projectDir, "Promptsfile.local"); source.File != want {
+			t.Errorf("trusted by %s, want %s", source.File, want)
+		}
+	})
+
+	t.Run("plain sources are not trusted", func(t *testing.T) {
+		if trusted.IsTrusted("github.com/evil/prompts") {
+			t.Fatalf("expected plain Promptsfile entry to stay untrusted")
+		}
+	})
+
+	t.Run("installer enforces the allow-list", func(t *testing.T) {
+		installer, err := workflow.New(workflow.InstallOptions{WorkspaceDir: projectDir, CacheDir: t.TempDir()})
+		if err != nil {
+			t.Fatalf("New returned error: %v", err)
+		}
+		err = installer.Execute()
+		if err == nil || !strings.Contains(err.Error(), "untrusted source: github.com/evil/prompts") {
+			t.Fatalf("expected untrusted source error, got %v", err)
+		}
+	})
+}
+
+func TestTrustedSources_ObjectSourcesAreNotTrusted(t *testing.T) {
+	projectDir := t.TempDir()
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+
+	writeFile(t, filepath.Join(projectDir, "Promptsfile"), `version: 1
+sources:
+  - name: evil
+    repo: github.com/evil/prompts
+    ref: main
+`)
+
+	trusted, err := security.LoadTrustedSources(projectDir)
+	if err != nil {
+		t.Fatalf("LoadTrustedSources returned error: %v", err)
+	}
+	if trusted.IsTrusted("github.com/evil/prompts") {
+		t.Fatalf("expected object-form source to stay untrusted")
+	}
+
+	installer, err := workflow.New(workflow.InstallOptions{WorkspaceDir: projectDir, CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	err = installer.Execute()
+	if err == nil || !strings.Contains(err.Error(), "untrusted source: github.com/evil/prompts") {
+		t.Fatalf("expected untrusted source error, got %v", err)
+	}
+}
+
+func TestTrustedSources_Precedence(t *testing.T) {
+	projectDir := t.TempDir()
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	writeFile(t, userConfig, `sources:
+  - name: org
+    repo: github.com:acme/*
+  - name: style
+    repo: github.com:acme/style-prompts
+  - repo: github.com:acme/zeta
+  - repo: github.com:acme/alpha
+`)
+	writeFile(t, filepath.Join(projectDir, "Promptsfile"), `trusted_sources:
+  - name: project
+    repo: github.com:acme/*
+    claude_prefix: proj
+`)
+	writeFile(t, filepath.Join(projectDir, "Promptsfile.local"), `trusted_sources:
+  - name: team
+    repo: github.com:acme/team-*
+`)
+
+	cfg, err := config.Load(projectDir)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	var repos []string
+	for _, source := range cfg.Sources {
+		repos = append(repos, source.Name+"="+source.Repo)
+	}
+	want := "=github.com:acme/alpha,=github.com:acme/zeta,org=github.com:acme/*,project=github.com:acme/*,style=github.com:acme/style-prompts,team=github.com:acme/team-*"
+	if got := strings.Join(repos, ","); got != want {
+		t.Fatalf("sources = %s, want %s", got, want)
+	}
+
+	trusted := security.NewTrustedSources(cfg)
+	for repo, name := range map[string]string{
+		"github.com/acme/style-prompts": "style",   // exact beats wildcards of higher files
+		"github.com/acme/other":         "project", // the Promptsfile overrides the user config
+		"github.com/acme/team-go":       "team",    // Promptsfile.local overrides the Promptsfile
+	} {
+		source, ok := trusted.TrustedBy(repo)
+		if !ok {
+			t.Fatalf("expected %s to be trusted", repo)
+		}
+		if source.Name != name {
+			t.Errorf("%s trusted by %s, want %s", repo, source.Name, name)
+		}
+	}
+}
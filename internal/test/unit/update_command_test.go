@@ -30,9 +30,9 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create initial Promptsfile with unpinned sources
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
-				"file://" + devRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
+				config.ParseSource("file://" + devRepo),
 			},
 			Adapters: config.AdaptersCfg{
 				Cursor: config.CursorCfg{Enabled: true},
@@ -65,9 +65,9 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
-				"file://" + devRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
+				config.ParseSource("file://" + devRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -98,10 +98,10 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile with pinned and unpinned sources
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#v1.0.0", // Pinned to specific version
-				"file://" + devRepo + "#master",  // Pinned to branch
-				"file://" + acmeRepo,             // Unpinned (duplicate for testing)
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#v1.0.0"), // Pinned to specific version
+				config.ParseSource("file://" + devRepo + "#master"),  // Pinned to branch
+				config.ParseSource("file://" + acmeRepo),             // Unpinned (duplicate for testing)
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -126,8 +126,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Verify Promptsfile wasn't changed (pinned versions should remain)
 		cfg := readPromptsfileForUpdate(t, tmpDir)
-		assert.Contains(t, cfg.Sources, "file://"+acmeRepo+"#v1.0.0")
-		assert.Contains(t, cfg.Sources, "file://"+devRepo+"#master")
+		assert.Contains(t, cfg.Sources, config.ParseSource("file://"+acmeRepo+"#v1.0.0"))
+		assert.Contains(t, cfg.Sources, config.ParseSource("file://"+devRepo+"#master"))
 	})
 
 	t.Run("handling breaking changes warnings", func(t *testing.T) {
@@ -138,8 +138,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -183,8 +183,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile but no lock file
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -204,8 +204,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -239,8 +239,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo,
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo),
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -268,8 +268,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile with version that can be updated
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#v1", // Major version constraint
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#v1"), // Major version constraint
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -299,8 +299,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile with pinned source
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#v1.0.0", // Pinned to specific version
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#v1.0.0"), // Pinned to specific version
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
@@ -331,8 +331,8 @@ func TestUpdateCommand(t *testing.T) {
 
 		// Create Promptsfile with pinned source
 		initialConfig := &config.ExtendedConfig{
-			Sources: []string{
-				"file://" + acmeRepo + "#v1.0.0", // Pinned to specific version
+			Sources: []config.SourceCfg{
+				config.ParseSource("file://" + acmeRepo + "#v1.0.0"), // Pinned to specific version
 			},
 			Adapters: config.AdaptersCfg{Cursor: config.CursorCfg{Enabled: true}},
 		}
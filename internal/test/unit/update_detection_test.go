@@ -0,0 +1,224 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/cmd"
+	"github.com/kovyrin/prompt-sync/internal/git"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestFetcher_ResolveRemote(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{"prompts/style.md": "# Style v1\n"})
+	first := runGitCmd(t, repo, "rev-parse", "HEAD")
+	runGitCmd(t, repo, "tag", "-a", "v1.0.0", "-m", "Release 1.0.0")
+	second := commitPromptFiles(t, repo, map[string]string{"prompts/style.md": "# Style v2\n"}, "Update style")
+	runGitCmd(t, repo, "tag", "v1.1.0")
+	third := commitPromptFiles(t, repo, map[string]string{"prompts/new.md": "# New\n"}, "Add prompt")
+
+	backends := []git.Backend{git.BackendGoGit, git.BackendExec}
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			fetcher := git.NewFetcherWithBackend(backend, git.WithCacheDir(t.TempDir()))
+
+			remote, err := fetcher.ResolveRemote(repo, "main")
+			require.NoError(t, err)
+			assert.Equal(t, third, remote.Commit)
+			assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, remote.TagNames())
+			assert.Equal(t, first, remote.Tags["v1.0.0"], "annotated tags resolve to their commit")
+			assert.Equal(t, second, remote.Tags["v1.1.0"])
+
+			remote, err = fetcher.ResolveRemote(repo, "")
+			require.NoError(t, err)
+			assert.Equal(t, third, remote.Commit, "empty ref resolves to the default branch")
+
+			remote, err = fetcher.ResolveRemote(repo, "v1.0.0")
+			require.NoError(t, err)
+			assert.Equal(t, first, remote.Commit)
+
+			_, err = fetcher.ResolveRemote(repo, "no-such-branch")
+			assert.Error(t, err)
+
+			behind, err := fetcher.CommitsBehind(repo, first, third)
+			require.NoError(t, err)
+			assert.Equal(t, 2, behind)
+
+			behind, err = fetcher.CommitsBehind(repo, third, third)
+			require.NoError(t, err)
+			assert.Equal(t, 0, behind)
+		})
+	}
+
+	t.Run("offline mode refuses remote queries", func(t *testing.T) {
+		fetcher := git.NewFetcher(git.WithCacheDir(t.TempDir()), git.WithOfflineMode())
+		_, err := fetcher.ResolveRemote(repo, "main")
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateCommand_DetectsUpdates(t *testing.T) {
+	stale := createPromptRepo(t, map[string]string{"prompts/stale.md": "# Stale v1\n"})
+	current := createPromptRepo(t, map[string]string{"prompts/current.md": "# Current\n"})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("PROMPT_SYNC_CACHE_DIR", cacheDir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+		[]byte("sources:\n  - "+stale+"#main\n  - "+current+"#main\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	lockBefore, err := lock.New(workDir).Read()
+	require.NoError(t, err)
+
+	// Only one source receives new commits
+	commitPromptFiles(t, stale, map[string]string{"prompts/stale.md": "# Stale v2\n"}, "Update")
+	latest := commitPromptFiles(t, stale, map[string]string{"prompts/extra.md": "# Extra\n"}, "Add extra")
+
+	oldWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(workDir))
+	defer os.Chdir(oldWd)
+
+	t.Run("dry run prints the update plan", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		err := runUpdateWithOutput(output, "--dry-run", "--allow-unknown", "--cache-dir", cacheDir)
+		require.NoError(t, err)
+
+		assert.Contains(t, output.String(), stale)
+		assert.Contains(t, output.String(), latest[:7])
+		assert.Contains(t, output.String(), "2 commit(s) behind")
+		assert.NotContains(t, output.String(), current, "up-to-date sources are not listed")
+
+		lockAfter, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		assert.Equal(t, lockBefore.Sources, lockAfter.Sources, "dry run must not touch the lock file")
+	})
+
+	t.Run("list --outdated shows only stale sources", func(t *testing.T) {
+		t.Cleanup(func() {
+			_ = cmd.ListCmd.Flags().Set("outdated", "false")
+			_ = cmd.ListCmd.Flags().Set("json", "false")
+		})
+
+		output := &bytes.Buffer{}
+		require.NoError(t, runListCommand(workDir, []string{"--outdated", "--json"}, output))
+
+		var result struct {
+			Sources []struct {
+				URL           string `json:"url"`
+				LatestCommit  string `json:"latest_commit"`
+				CommitsBehind int    `json:"commits_behind"`
+			} `json:"sources"`
+		}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+		require.Len(t, result.Sources, 1)
+		assert.Equal(t, stale, result.Sources[0].URL)
+		assert.Equal(t, latest, result.Sources[0].LatestCommit)
+		assert.Equal(t, 2, result.Sources[0].CommitsBehind)
+	})
+
+	t.Run("list --outdated --offline only reads the cache", func(t *testing.T) {
+		t.Cleanup(func() {
+			_ = cmd.ListCmd.Flags().Set("outdated", "false")
+			_ = cmd.ListCmd.Flags().Set("json", "false")
+			_ = cmd.ListCmd.Flags().Set("offline", "false")
+			_ = cmd.ListCmd.Flags().Set("cache-dir", "")
+		})
+
+		emptyCache := t.TempDir()
+		output := &bytes.Buffer{}
+		require.NoError(t, runListCommand(workDir, []string{"--outdated", "--json", "--offline", "--cache-dir", emptyCache}, output))
+
+		entries, err := os.ReadDir(emptyCache)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "nothing is cloned into the cache")
+		assert.Contains(t, output.String(), "offline")
+	})
+
+	t.Run("update moves the stale source to the latest commit", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runUpdateWithOutput(output, "--allow-unknown", "--cache-dir", cacheDir))
+
+		lockAfter, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		for _, source := range lockAfter.Sources {
+			if source.URL == stale {
+				assert.Equal(t, latest, source.Commit)
+			}
+		}
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/extra.mdc"))
+	})
+}
+
+func TestUpdateCommand_PackDependencies(t *testing.T) {
+	shared := createPromptRepo(t, map[string]string{
+		"base/pack.yaml":       "name: base\nversion: 1.0.0\n",
+		"base/prompts/base.md": "Be kind.\n",
+	})
+	repo := createPromptRepo(t, map[string]string{
+		"ruby/pack.yaml":        "name: ruby-style\ndependencies: [shared/base@^1]\n",
+		"ruby/prompts/style.md": "Use rubocop.\n",
+	})
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(userConfig, []byte("sources:\n  - name: shared\n    repo: "+shared+"\n"), 0644))
+	t.Setenv("PROMPT_SYNC_USER_CONFIG", userConfig)
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("PROMPT_SYNC_CACHE_DIR", cacheDir)
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(`version: 1
+sources:
+  - name: acme
+    repo: `+repo+`
+    ref: main
+rulesets:
+  - acme/ruby-style
+`), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	latest := commitPromptFiles(t, shared, map[string]string{"base/prompts/extra.md": "Be brief.\n"}, "Add extra")
+
+	oldWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(workDir))
+	defer os.Chdir(oldWd)
+
+	t.Run("dry run lists outdated dependencies", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runUpdateWithOutput(output, "--dry-run", "--allow-unknown", "--cache-dir", cacheDir))
+		assert.Contains(t, output.String(), shared+"//base (default branch)")
+		assert.Contains(t, output.String(), latest[:7])
+	})
+
+	t.Run("dependencies can be updated by the name packs require them by", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runUpdateWithOutput(output, "--allow-unknown", "--cache-dir", cacheDir, "shared/base"))
+
+		lockAfter, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		commits := make(map[string]string)
+		for _, source := range lockAfter.Sources {
+			commits[source.URL] = source.Commit
+		}
+		assert.Equal(t, latest, commits[shared+"//base"])
+		assert.FileExists(t, filepath.Join(workDir, ".cursor/rules/_active/extra.mdc"))
+	})
+}
+
+func runUpdateWithOutput(output *bytes.Buffer, args ...string) error {
+	rootCmd := &cobra.Command{Use: "prompt-sync"}
+	rootCmd.AddCommand(cmd.NewUpdateCommand())
+	rootCmd.SetOut(output)
+	rootCmd.SetErr(output)
+	rootCmd.SetArgs(append([]string{"update"}, args...))
+	return rootCmd.Execute()
+}
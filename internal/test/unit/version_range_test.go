@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+// createTaggedPromptRepo creates a repository with one commit per tag, each
+// writing the tag name into prompts/version.md. It returns the tag commits.
+func createTaggedPromptRepo(t *testing.T, tags ...string) (string, map[string]string) {
+	t.Helper()
+
+	repo := createPromptRepo(t, map[string]string{"prompts/version.md": "untagged\n"})
+	commits := make(map[string]string)
+	for _, tag := range tags {
+		commits[tag] = commitPromptFiles(t, repo, map[string]string{"prompts/version.md": tag + "\n"}, "Release "+tag)
+		runGitCmd(t, repo, "tag", tag)
+	}
+	return repo, commits
+}
+
+func TestInstall_VersionRange(t *testing.T) {
+	repo, commits := createTaggedPromptRepo(t, "v1.0.0", "v1.2.0", "v1.3.1", "v2.0.0")
+	workDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+		[]byte("sources:\n  - "+repo+"#^1.2\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{}))
+
+	content, err := os.ReadFile(filepath.Join(workDir, ".cursor/rules/_active/version.mdc"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.1\n", string(content), "highest tag within the range is installed")
+
+	lockData, err := lock.New(workDir).Read()
+	require.NoError(t, err)
+	require.Len(t, lockData.Sources, 1)
+	assert.Equal(t, "^1.2", lockData.Sources[0].Ref)
+	assert.Equal(t, "v1.3.1", lockData.Sources[0].Version)
+	assert.Equal(t, commits["v1.3.1"], lockData.Sources[0].Commit)
+
+	t.Run("no matching tag", func(t *testing.T) {
+		workDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"),
+			[]byte("sources:\n  - "+repo+"#^3.0\n"), 0644))
+
+		err := runInstall(t, workDir, t.TempDir(), workflow.InstallOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no tag matches version range ^3.0")
+	})
+}
+
+func TestUpdateCommand_VersionRange(t *testing.T) {
+	repo, commits := createTaggedPromptRepo(t, "v1.2.0", "v2.0.0")
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("PROMPT_SYNC_CACHE_DIR", cacheDir)
+
+	promptsfile := filepath.Join(workDir, "Promptsfile")
+	require.NoError(t, os.WriteFile(promptsfile, []byte("sources:\n  - "+repo+"#^1.2\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	// A new release within the range is published
+	commitPromptFiles(t, repo, map[string]string{"prompts/version.md": "v1.3.0\n"}, "Release v1.3.0")
+	runGitCmd(t, repo, "tag", "v1.3.0")
+	commits["v1.3.0"] = runGitCmd(t, repo, "rev-parse", "HEAD")
+
+	oldWd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(workDir))
+	defer os.Chdir(oldWd)
+
+	readLocked := func() lock.Source {
+		lockData, err := lock.New(workDir).Read()
+		require.NoError(t, err)
+		require.Len(t, lockData.Sources, 1)
+		return lockData.Sources[0]
+	}
+
+	t.Run("update stays within the range", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runUpdateWithOutput(output, "--allow-unknown", "--cache-dir", cacheDir))
+
+		locked := readLocked()
+		assert.Equal(t, "v1.3.0", locked.Version)
+		assert.Equal(t, commits["v1.3.0"], locked.Commit)
+
+		content, err := os.ReadFile(promptsfile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), repo+"#^1.2", "Promptsfile keeps the range")
+	})
+
+	t.Run("a failed major update leaves the Promptsfile as it was", func(t *testing.T) {
+		// The untrusted source fails the install once the range is rewritten
+		output := &bytes.Buffer{}
+		require.Error(t, runUpdateWithOutput(output, "--major", "--cache-dir", cacheDir))
+
+		content, err := os.ReadFile(promptsfile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), repo+"#^1.2")
+		assert.Equal(t, "v1.3.0", readLocked().Version)
+	})
+
+	t.Run("update --major crosses to the next major version", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		require.NoError(t, runUpdateWithOutput(output, "--major", "--allow-unknown", "--cache-dir", cacheDir))
+
+		locked := readLocked()
+		assert.Equal(t, "^2.0.0", locked.Ref)
+		assert.Equal(t, "v2.0.0", locked.Version)
+		assert.Equal(t, commits["v2.0.0"], locked.Commit)
+
+		content, err := os.ReadFile(promptsfile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), repo+"#^2.0.0")
+	})
+}
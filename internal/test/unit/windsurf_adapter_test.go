@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kovyrin/prompt-sync/internal/workflow"
+)
+
+func TestInstall_WindsurfAdapter(t *testing.T) {
+	repo := createPromptRepo(t, map[string]string{
+		"prompts/style.md":       "---\nalwaysApply: true\n---\n# Style\n",
+		"prompts/go.md":          "---\ndescription: Go conventions\nglobs: ['**/*.go', '**/go.mod']\n---\n# Go\n",
+		"prompts/review.md":      "---\ndescription: Use when reviewing a pull request\n---\n# Review\n",
+		"prompts/legacy.md":      "# Legacy\n",
+		"prompts/lint.mdc":       "---\nalwaysApply: true\n---\n# Lint\n",
+		"prompts/notes.markdown": "# Notes\n",
+		"prompts/release.md":     "---\nkind: command\n---\nTag and publish.\n",
+	})
+
+	workDir := t.TempDir()
+	cacheDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Promptsfile"), []byte(
+		"sources:\n  - "+repo+"#main\nadapters:\n  windsurf:\n    enabled: true\n"), 0644))
+	require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{}))
+
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("triggers are mapped from Cursor-style fields", func(t *testing.T) {
+		assert.Equal(t, "---\ntrigger: always_on\n---\n\n# Style\n", read(".windsurf/rules/style.md"))
+		assert.Equal(t, "---\ntrigger: glob\ndescription: Go conventions\nglobs: '**/*.go,**/go.mod'\n---\n\n# Go\n", read(".windsurf/rules/go.md"))
+		assert.Equal(t, "---\ntrigger: model_decision\ndescription: Use when reviewing a pull request\n---\n\n# Review\n", read(".windsurf/rules/review.md"))
+		assert.Equal(t, "---\ntrigger: manual\n---\n\n# Legacy\n", read(".windsurf/rules/legacy.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".windsurf/rules/release.md"), "commands are not rules")
+	})
+
+	t.Run("rules are written as .md whatever the source extension", func(t *testing.T) {
+		assert.Equal(t, "---\ntrigger: always_on\n---\n\n# Lint\n", read(".windsurf/rules/lint.md"))
+		assert.Equal(t, "---\ntrigger: manual\n---\n\n# Notes\n", read(".windsurf/rules/notes.md"))
+		assert.NoFileExists(t, filepath.Join(workDir, ".windsurf/rules/lint.mdc"))
+		assert.Equal(t, ".windsurf/rules/lint.md", lockedFile(t, workDir, "prompts/lint.mdc").Path)
+	})
+
+	t.Run("rules are locked and ignored", func(t *testing.T) {
+		assert.Equal(t, ".windsurf/rules/go.md", lockedFile(t, workDir, "prompts/go.md").Path)
+		assert.Contains(t, read(".gitignore"), ".windsurf/rules/")
+	})
+
+	t.Run("removed rules are cleaned up", func(t *testing.T) {
+		runGitCmd(t, repo, "rm", "-q", "prompts/go.md")
+		runGitCmd(t, repo, "commit", "-m", "Remove go rule")
+		require.NoError(t, runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}}))
+
+		assert.NoFileExists(t, filepath.Join(workDir, ".windsurf/rules/go.md"))
+		assert.FileExists(t, filepath.Join(workDir, ".windsurf/rules/style.md"))
+	})
+
+	t.Run("oversized rules are rejected", func(t *testing.T) {
+		commitPromptFiles(t, repo, map[string]string{
+			"prompts/huge.md": "# Huge\n" + strings.Repeat("x", 12000) + "\n",
+		}, "Add huge rule")
+		err := runInstall(t, workDir, cacheDir, workflow.InstallOptions{UpdateSources: []string{repo}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "prompts/huge.md renders to")
+		assert.Contains(t, err.Error(), "over the Windsurf limit of 12000")
+	})
+}
@@ -0,0 +1,7 @@
+// Package version holds the prompt-sync release version.
+package version
+
+// Version is the prompt-sync release. Release builds and make build set it
+// with -ldflags "-X github.com/kovyrin/prompt-sync/internal/version.Version=1.2.3";
+// plain go build keeps this default.
+var Version = "0.1.0"
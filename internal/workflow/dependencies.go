@@ -0,0 +1,184 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/config"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/pack"
+	"github.com/kovyrin/prompt-sync/internal/security"
+)
+
+// dependencyResolver adds the packs required by pack.yaml manifests to the
+// sources of an install, transitively. A required pack that no source
+// installs yet is fetched from its source at that source's ref; its
+// version must satisfy the range of every pack that requires it.
+type dependencyResolver struct {
+	installer *Installer
+	cfg       *config.ExtendedConfig
+	locked    map[string]lock.Source
+
+	sources []config.SourceCfg
+	scopes  []string
+	fetched []fetchedSource
+	deps    [][]lock.Dependency // Resolved dependencies of each source
+
+	index    map[string]int   // Source URL -> index
+	visiting map[int]bool     // Sources whose dependencies are being resolved
+	done     map[int]bool     // Sources whose dependencies are resolved
+	required map[int][]string // Packs requiring each source, for conflict reports
+}
+
+func newDependencyResolver(i *Installer, cfg *config.ExtendedConfig, locked map[string]lock.Source, sources []config.SourceCfg, scopes []string, fetched []fetchedSource) *dependencyResolver {
+	r := &dependencyResolver{
+		installer: i,
+		cfg:       cfg,
+		locked:    locked,
+		sources:   sources,
+		scopes:    scopes,
+		fetched:   fetched,
+		deps:      make([][]lock.Dependency, len(sources)),
+		index:     make(map[string]int),
+		visiting:  make(map[int]bool),
+		done:      make(map[int]bool),
+		required:  make(map[int][]string),
+	}
+	for idx, source := range sources {
+		if _, ok := r.index[source.URL()]; !ok {
+			r.index[source.URL()] = idx
+		}
+	}
+	return r
+}
+
+// resolve resolves the dependencies of every source. Sources it adds are
+// resolved as they are added.
+func (r *dependencyResolver) resolve() error {
+	for idx := range r.sources {
+		if err := r.visit(idx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visit resolves the dependencies of a source depth first. stack holds the
+// names of the packs being resolved to report cycles.
+func (r *dependencyResolver) visit(idx int, stack []string) error {
+	manifest := r.fetched[idx].manifest
+	if r.done[idx] || manifest == nil {
+		return nil
+	}
+	r.visiting[idx] = true
+	stack = append(stack[:len(stack):len(stack)], manifest.Name)
+
+	requires, err := manifest.Requires()
+	if err != nil {
+		return fmt.Errorf("pack %s: %w", manifest.Name, err)
+	}
+	for _, dep := range requires {
+		j, err := r.add(idx, dep)
+		if err != nil {
+			return fmt.Errorf("pack %s: dependency %s: %w", manifest.Name, dep, err)
+		}
+
+		required := r.fetched[j].manifest
+		if dep.Range != "" && (required == nil || !required.Satisfies(dep.Range)) {
+			return r.conflict(j, manifest.Name, dep)
+		}
+		if r.visiting[j] {
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(stack, " -> "), r.name(j))
+		}
+		r.required[j] = append(r.required[j], manifest.Name+" ("+rangeOrAny(dep.Range)+")")
+
+		resolved := lock.Dependency{Pack: dep.Name(), Range: dep.Range, URL: r.sources[j].URL()}
+		if required != nil {
+			resolved.Version = required.Version
+		}
+		r.deps[idx] = append(r.deps[idx], resolved)
+
+		if err := r.visit(j, stack); err != nil {
+			return err
+		}
+	}
+
+	r.visiting[idx] = false
+	r.done[idx] = true
+	return nil
+}
+
+// add returns the index of the source that installs a dependency of the
+// source at idx, fetching it when no source installs it yet
+func (r *dependencyResolver) add(idx int, dep pack.Dependency) (int, error) {
+	// Packs of the same source share its checkout and settings
+	source := r.sources[idx]
+	if dep.Source != "" {
+		named, err := r.cfg.NamedSource(dep.Source, r.installer.trustedSources.Named)
+		if err != nil {
+			return 0, err
+		}
+		source = named
+	}
+	source.Pack, source.Ruleset = dep.Pack, ""
+
+	url := source.URL()
+	if j, ok := r.index[url]; ok {
+		return j, nil
+	}
+
+	i := r.installer
+	if !i.trustedSources.IsTrusted(source.Repo) && !i.opts.AllowUnknown {
+		return 0, security.UntrustedError(source.Repo)
+	}
+	if _, ok := r.locked[url]; !ok && i.opts.FrozenLockfile {
+		return 0, fmt.Errorf("frozen lockfile: %s is not in the lock file", url)
+	}
+	fetched, err := i.fetch(source, r.locked)
+	if err != nil {
+		return 0, err
+	}
+	if fetched.manifest == nil {
+		return 0, fmt.Errorf("%s has no %s", url, pack.ManifestFile)
+	}
+
+	j := len(r.sources)
+	r.sources = append(r.sources, source)
+	r.scopes = append(r.scopes, r.scopes[idx])
+	r.fetched = append(r.fetched, fetched)
+	r.deps = append(r.deps, nil)
+	r.index[url] = j
+	return j, nil
+}
+
+// conflict reports a required pack whose version is outside the range a
+// dependent requires
+func (r *dependencyResolver) conflict(j int, dependent string, dep pack.Dependency) error {
+	version := "unversioned"
+	if manifest := r.fetched[j].manifest; manifest != nil && manifest.Version != "" {
+		version = manifest.Version
+	}
+
+	msg := fmt.Sprintf("version conflict: %s requires %s %s, but %s is %s", dependent, r.name(j), dep.Range, r.name(j), version)
+	if others := r.required[j]; len(others) > 0 {
+		msg += fmt.Sprintf("; also required by %s", strings.Join(others, ", "))
+	}
+	return errors.New(msg)
+}
+
+// name returns the name of the pack a source installs
+func (r *dependencyResolver) name(idx int) string {
+	if manifest := r.fetched[idx].manifest; manifest != nil {
+		return manifest.Name
+	}
+	return r.sources[idx].URL()
+}
+
+// rangeOrAny describes a version range for messages
+func rangeOrAny(versionRange string) string {
+	if versionRange == "" {
+		return "any version"
+	}
+	return versionRange
+}
@@ -1,20 +1,29 @@
 package workflow
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/kovyrin/prompt-sync/internal/adapter"
-	"github.com/kovyrin/prompt-sync/internal/adapter/claude"
-	"github.com/kovyrin/prompt-sync/internal/adapter/cursor"
+	"github.com/kovyrin/prompt-sync/internal/adapter/registry"
 	"github.com/kovyrin/prompt-sync/internal/config"
 	"github.com/kovyrin/prompt-sync/internal/conflict"
 	"github.com/kovyrin/prompt-sync/internal/git"
 	"github.com/kovyrin/prompt-sync/internal/gitignore"
 	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/managed"
+	"github.com/kovyrin/prompt-sync/internal/pack"
+	"github.com/kovyrin/prompt-sync/internal/policy"
 	"github.com/kovyrin/prompt-sync/internal/security"
+	"github.com/kovyrin/prompt-sync/internal/semver"
+	"github.com/kovyrin/prompt-sync/internal/version"
 )
 
 // InstallOptions contains options for the install workflow
@@ -25,6 +34,26 @@ type InstallOptions struct {
 	Offline      bool
 	CacheDir     string
 	AllowUnknown bool
+
+	// FrozenLockfile fails the install when the Promptsfile and the lock file
+	// disagree instead of re-resolving the changed sources.
+	FrozenLockfile bool
+
+	// UpdateSources lists source URLs that should be re-resolved from their
+	// refs even though the lock file pins them to a commit.
+	UpdateSources []string
+
+	// Jobs is the maximum number of sources fetched and rendered concurrently.
+	// Zero or less uses the number of CPUs.
+	Jobs int
+
+	// SecurityThreshold overrides the Promptsfile security threshold. Prompts
+	// above it produce a warning, or an error in strict mode.
+	SecurityThreshold string
+
+	// ApproveSecurity accepts prompts whose security level increased since
+	// the lock file was written.
+	ApproveSecurity bool
 }
 
 // Installer orchestrates the installation workflow
@@ -35,8 +64,9 @@ type Installer struct {
 	gitignoreManager *gitignore.Manager
 	lockWriter       *lock.Writer
 	conflictDetector *conflict.Detector
-	adapters         map[string]adapter.Adapter
+	adapters         *registry.Registry
 	trustedSources   *security.TrustedSources
+	policy           *policy.Policy
 }
 
 // New creates a new installer
@@ -51,8 +81,17 @@ func New(opts InstallOptions) (*Installer, error) {
 	// Initialize components
 	configLoader := config.NewLoader(promptsDir)
 
-	// Initialize trusted sources
-	trustedSources := security.NewTrustedSources()
+	// Build the allow-list and locate the policy from the layered configuration
+	layeredCfg, err := config.Load(promptsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	trustedSources := security.NewTrustedSources(layeredCfg)
+
+	orgPolicy, err := policy.Load(policy.Path(layeredCfg))
+	if err != nil {
+		return nil, err
+	}
 
 	// Create git fetcher with options
 	gitOpts := []git.Option{
@@ -68,12 +107,6 @@ func New(opts InstallOptions) (*Installer, error) {
 	lockWriter := lock.New(promptsDir)
 	conflictDetector := conflict.New(opts.StrictMode)
 
-	// Initialize adapters
-	adapters := map[string]adapter.Adapter{
-		"cursor": cursor.NewSimpleAdapter(),
-		"claude": claude.NewSimpleAdapter(),
-	}
-
 	return &Installer{
 		opts:             opts,
 		configLoader:     configLoader,
@@ -81,12 +114,14 @@ func New(opts InstallOptions) (*Installer, error) {
 		gitignoreManager: gitignoreManager,
 		lockWriter:       lockWriter,
 		conflictDetector: conflictDetector,
-		adapters:         adapters,
+		adapters:         registry.Default(),
 		trustedSources:   trustedSources,
+		policy:           orgPolicy,
 	}, nil
 }
 
-// SetGitFetcher allows replacing the git fetcher (primarily for testing)
+// SetGitFetcher allows replacing the git fetcher (primarily for testing).
+// The fetcher must be safe for concurrent use, sources are fetched in parallel.
 func (i *Installer) SetGitFetcher(fetcher git.Fetcher) {
 	i.gitFetcher = fetcher
 }
@@ -98,12 +133,40 @@ func (i *Installer) Execute() error {
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	adapters, err := i.adapters.Build(cfg.Adapters.Enabled())
+	if err != nil {
+		return err
+	}
+
+	thresholdName := cfg.Security.Threshold
+	if i.opts.SecurityThreshold != "" {
+		thresholdName = i.opts.SecurityThreshold
+	}
+	threshold, err := security.ParseLevel(thresholdName)
+	if err != nil {
+		return fmt.Errorf("invalid security threshold: %w", err)
+	}
 
 	// In verify mode, check if lock file exists
 	if i.opts.VerifyOnly && !i.lockWriter.Exists() {
 		return fmt.Errorf("lock file not found, run install first")
 	}
 
+	// Take the workspace for this install, rolling back an install that was
+	// interrupted while changing it
+	var txn *transaction
+	if !i.opts.VerifyOnly {
+		var recovered bool
+		txn, recovered, err = beginTransaction(i.opts.WorkspaceDir)
+		if err != nil {
+			return err
+		}
+		defer txn.discard()
+		if recovered {
+			fmt.Println("Warning: rolled back an interrupted install")
+		}
+	}
+
 	// Read existing lock file to track old files for cleanup
 	oldLock, err := i.lockWriter.Read()
 	if err != nil {
@@ -112,150 +175,400 @@ func (i *Installer) Execute() error {
 
 	// Create a map of old files per source for efficient lookup
 	oldFilesBySource := make(map[string][]lock.File)
+	lockedSources := make(map[string]lock.Source)
+	lockedFiles := make(map[string]lock.File)
 	if oldLock != nil {
 		for _, source := range oldLock.Sources {
 			baseURL := strings.Split(source.URL, "#")[0]
 			oldFilesBySource[baseURL] = source.Files
+			lockedSources[baseURL] = source
+			for _, file := range source.Files {
+				lockedFiles[file.Path] = file
+			}
 		}
 	}
 
+	// Rulesets pick packs of named sources
+	selected, err := cfg.Selected(i.trustedSources.Named)
+	if err != nil {
+		return err
+	}
+
 	// Validate sources against trusted list
-	for _, source := range cfg.Sources {
-		url := strings.Split(source, "#")[0] // Remove ref if present
-		if !i.trustedSources.IsTrusted(url) && !i.opts.AllowUnknown {
-			return fmt.Errorf("untrusted source: %s", url)
+	for _, source := range selected {
+		if !i.trustedSources.IsTrusted(source.Repo) && !i.opts.AllowUnknown {
+			return security.UntrustedError(source.Repo)
 		}
 	}
 
 	// Process overlays if configured
-	allSources := append([]string{}, cfg.Sources...)
+	allSources := append([]config.SourceCfg{}, selected...)
+	scopes := make([]string, len(allSources))
+	for idx := range scopes {
+		scopes[idx] = scopeProject
+	}
 	for _, overlay := range cfg.Overlays {
-		url := strings.Split(overlay.Source, "#")[0]
-		if !i.trustedSources.IsTrusted(url) && !i.opts.AllowUnknown {
-			return fmt.Errorf("untrusted overlay source: %s", url)
+		source := config.ParseSource(overlay.Source)
+		if !i.trustedSources.IsTrusted(source.Repo) && !i.opts.AllowUnknown {
+			return fmt.Errorf("untrusted overlay source: %s", source.Repo)
+		}
+		scope, err := normalizeScope(overlay.Scope)
+		if err != nil {
+			return fmt.Errorf("overlay %s: %w", source.URL(), err)
 		}
-		allSources = append(allSources, overlay.Source)
+		allSources = append(allSources, source)
+		scopes = append(scopes, scope)
 	}
 
-	// Clone/update repositories
-	var lockSources []lock.Source
-	renderedFiles := make(map[string]string) // path -> source URL
-
-	for _, source := range allSources {
-		parts := strings.Split(source, "#")
-		url := parts[0]
-		ref := ""
-		if len(parts) > 1 {
-			ref = parts[1]
+	// In frozen mode the lock file is the only source of truth
+	if i.opts.FrozenLockfile {
+		if err := checkLockfileInSync(allSources, oldLock); err != nil {
+			return err
 		}
+	}
 
-		// Clone or update the repository
-		repoPath, commit, err := i.gitFetcher.CloneOrUpdate(url, ref)
-		if err != nil {
-			return fmt.Errorf("failed to fetch %s: %w", url, err)
+	// Fetch and render all sources concurrently
+	results, err := i.prepareSources(adapters, allSources, scopes, lockedSources, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Higher scopes shadow files with the same output path from lower scopes,
+	// except fragments, which are composed into one file
+	if err := resolveShadowing(results); err != nil {
+		return err
+	}
+	if err := composeFragments(results); err != nil {
+		return err
+	}
+
+	// In verify mode, compare the workspace against the lock file
+	if i.opts.VerifyOnly {
+		return i.verify(adapters, results, oldLock, lockedFiles)
+	}
+
+	// Every output path has a single owner after shadowing
+	renderedFiles := make(map[string]string) // path -> source URL
+	for _, result := range results {
+		for _, file := range result.files {
+			renderedFiles[file.outputPath] = result.url
 		}
+	}
+
+	// Evaluate the organisation policy and security levels before anything is written
+	if err := i.checkPolicy(adapters, results); err != nil {
+		return err
+	}
+	if err := i.checkSecurityLevels(results, threshold, oldLock, lockedFiles); err != nil {
+		return err
+	}
 
-		// Process each enabled adapter
+	// Stage the rendered files so nothing in the workspace changes until every
+	// check has passed
+	var lockSources []lock.Source
+	var orphans []string
+	for _, result := range results {
 		var lockFiles []lock.File
+		for _, file := range result.files {
+			lockFile, err := i.stageFile(txn, file)
+			if err != nil {
+				return err
+			}
+			lockFile.Target = file.target
+			lockFile.Includes = file.includes
+			if file.securityLevel != security.LevelNone {
+				lockFile.Security = file.securityLevel.String()
+			}
+			// Keep recording the approved level until the increase is approved
+			old, found := lockedFiles[file.outputPath]
+			if approved := approvedLevel(oldLock, old, found, file.securityLevel); file.securityLevel > approved && !i.opts.ApproveSecurity {
+				lockFile.ApprovedSecurity = approved.String()
+			}
+			lockFiles = append(lockFiles, lockFile)
+		}
 
-		for name, adapterImpl := range i.adapters {
-			if !i.isAdapterEnabled(cfg, name) {
-				continue
+		// Files this source no longer renders are removed, unless another
+		// source now renders the same path
+		if oldFiles, exists := oldFilesBySource[result.url]; exists {
+			for _, orphan := range i.findOrphanedFiles(oldFiles, lockFiles) {
+				if _, owned := renderedFiles[orphan]; !owned {
+					orphans = append(orphans, orphan)
+				}
 			}
+		}
+
+		lockSources = append(lockSources, lock.Source{
+			URL:      result.url,
+			Ref:      result.ref,
+			Version:  result.version,
+			Scope:    result.scope,
+			Commit:   result.commit,
+			Files:    lockFiles,
+			Shadowed: result.shadowed,
+			Skipped:  result.skipped,
 
-			adapterCfg := i.getAdapterConfig(cfg, name)
+			PackVersion:  result.packVersion,
+			Dependencies: result.dependencies,
+		})
+	}
 
-			// Discover prompt files
-			files, err := adapterImpl.DiscoverFiles(repoPath)
-			if err != nil {
-				return fmt.Errorf("failed to discover files for %s: %w", name, err)
-			}
+	// Check what the output directories will contain once the install is applied
+	if err := i.checkStagedConflicts(adapters, renderedFiles, orphans); err != nil {
+		return err
+	}
 
-			// Render files
-			for _, file := range files {
-				outputPath := adapterImpl.GetOutputPath(file, adapterCfg)
-				fullOutputPath := filepath.Join(i.opts.WorkspaceDir, outputPath)
+	// Collect .gitignore patterns
+	packs := make([]adapter.PromptPack, 0, len(results))
+	for _, result := range results {
+		packs = append(packs, result.pack)
+	}
+	ignorePatterns := []string{transactionDirName + "/"}
+	for _, a := range adapters {
+		ignorePatterns = append(ignorePatterns, a.GitignorePatterns(packs)...)
+	}
 
-				// Track for conflict detection
-				if existing, exists := renderedFiles[outputPath]; exists {
-					if !i.opts.VerifyOnly {
-						return fmt.Errorf("conflict: %s would be rendered by both %s and %s", outputPath, existing, url)
-					}
-				}
-				renderedFiles[outputPath] = url
-
-				if !i.opts.VerifyOnly {
-					// Read file content
-					content, err := os.ReadFile(filepath.Join(repoPath, file))
-					if err != nil {
-						return fmt.Errorf("failed to read %s: %w", file, err)
-					}
-
-					// Render the file
-					rendered, err := adapterImpl.RenderFile(file, content, adapterCfg)
-					if err != nil {
-						return fmt.Errorf("failed to render %s: %w", file, err)
-					}
-
-					// Create output directory
-					outputDir := filepath.Dir(fullOutputPath)
-					if err := os.MkdirAll(outputDir, 0755); err != nil {
-						return fmt.Errorf("failed to create directory %s: %w", outputDir, err)
-					}
-
-					// Write rendered file
-					if err := os.WriteFile(fullOutputPath, rendered, 0644); err != nil {
-						return fmt.Errorf("failed to write %s: %w", fullOutputPath, err)
-					}
+	// Every path the install may touch is backed up before the first change
+	installPaths := make([]string, 0, len(renderedFiles))
+	for path := range renderedFiles {
+		installPaths = append(installPaths, path)
+	}
+	sort.Strings(installPaths)
+
+	var touched []string
+	for _, path := range installPaths {
+		touched = append(touched, filepath.Join(i.opts.WorkspaceDir, path))
+	}
+	for _, orphan := range orphans {
+		touched = append(touched, filepath.Join(i.opts.WorkspaceDir, orphan))
+	}
+	sort.Strings(touched)
+	touched = append(touched, i.gitignoreManager.Path(), i.lockWriter.Path())
+
+	// Swap the staged files into place and write the lock file; any failure
+	// restores the previous workspace
+	return txn.commit(touched, func() error {
+		for _, path := range installPaths {
+			if err := txn.install(path); err != nil {
+				return err
+			}
+		}
+
+		for _, orphan := range orphans {
+			fullPath := filepath.Join(i.opts.WorkspaceDir, orphan)
+			if lockedFiles[orphan].Section {
+				if err := removeSection(fullPath); err != nil {
+					return fmt.Errorf("failed to remove managed section of %s: %w", orphan, err)
 				}
+			} else if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove orphaned file %s: %w", orphan, err)
+			}
+			i.removeEmptyDirs(adapters, orphan)
+		}
 
-				// Calculate hash for lock file
-				hash, err := i.lockWriter.CalculateFileHash(fullOutputPath)
+		if err := i.gitignoreManager.Update(ignorePatterns); err != nil {
+			return fmt.Errorf("failed to update .gitignore: %w", err)
+		}
+
+		if err := i.lockWriter.Write(lockSources); err != nil {
+			return fmt.Errorf("failed to write lock file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// stageFile stages a rendered file and returns its lock entry. Managed
+// sections are merged into the file already in the workspace and only the
+// section is hashed.
+func (i *Installer) stageFile(txn *transaction, file renderedFile) (lock.File, error) {
+	content := file.content
+	if file.section {
+		existing, err := os.ReadFile(filepath.Join(i.opts.WorkspaceDir, file.outputPath))
+		if err != nil && !os.IsNotExist(err) {
+			return lock.File{}, fmt.Errorf("failed to read %s: %w", file.outputPath, err)
+		}
+		content = managed.Merge(existing, content)
+	}
+
+	stagedPath, err := txn.stage(file.outputPath, content)
+	if err != nil {
+		return lock.File{}, err
+	}
+
+	// Calculate hash for lock file
+	var hash string
+	if file.section {
+		hash, _ = managed.Hash(content)
+	} else {
+		hash, err = i.lockWriter.CalculateFileHash(stagedPath)
+		if err != nil {
+			return lock.File{}, fmt.Errorf("failed to calculate hash for %s: %w", file.outputPath, err)
+		}
+	}
+
+	return lock.File{
+		Path:       file.outputPath,
+		SourcePath: file.sourcePath,
+		Hash:       hash,
+		Section:    file.section,
+	}, nil
+}
+
+// removeSection strips the managed section from a file and removes the file
+// when nothing else is left in it
+func removeSection(path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := managed.Remove(content)
+	if len(bytes.TrimSpace(remaining)) == 0 {
+		return os.Remove(path)
+	}
+	return os.WriteFile(path, remaining, 0644)
+}
+
+// checkPolicy evaluates the organisation policy against the prepared sources.
+// Violations are warnings unless running in strict mode.
+func (i *Installer) checkPolicy(adapters []adapter.AgentAdapter, results []preparedSource) error {
+	if i.policy == nil {
+		return nil
+	}
+
+	install := policy.Install{
+		AllowUnknown: i.opts.AllowUnknown,
+		ToolVersion:  version.Version,
+	}
+	for _, a := range adapters {
+		install.Adapters = append(install.Adapters, a.Name())
+	}
+	for _, result := range results {
+		install.Sources = append(install.Sources, policy.Source{URL: result.url, Ref: result.ref, Scope: result.scope})
+		for _, file := range result.files {
+			install.Files = append(install.Files, policy.File{
+				Path:          file.outputPath,
+				SourceURL:     result.url,
+				Scope:         result.scope,
+				SecurityLevel: file.securityLevel,
+			})
+		}
+	}
+
+	issues := i.policy.Check(install)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, fmt.Sprintf("%s: %s", issue.Path, issue.Details))
+	}
+	if i.opts.StrictMode {
+		return fmt.Errorf("policy violations (%s):\n  %s", i.policy.Path, strings.Join(messages, "\n  "))
+	}
+	for _, message := range messages {
+		fmt.Printf("Warning: policy: %s\n", message)
+	}
+	return nil
+}
+
+// checkStagedConflicts scans each enabled adapter's output directory as it
+// will look after the install: existing files minus orphans plus staged files.
+func (i *Installer) checkStagedConflicts(adapters []adapter.AgentAdapter, renderedFiles map[string]string, orphans []string) error {
+	removed := make(map[string]bool)
+	for _, orphan := range orphans {
+		removed[filepath.Clean(orphan)] = true
+	}
+
+	for _, outputDir := range scanDirs(adapters) {
+		fullOutputDir := filepath.Join(i.opts.WorkspaceDir, outputDir)
+
+		future := make(map[string]bool)
+		if info, err := os.Stat(fullOutputDir); err == nil && info.IsDir() {
+			err := filepath.Walk(fullOutputDir, func(path string, info os.FileInfo, err error) error {
 				if err != nil {
-					if i.opts.VerifyOnly && os.IsNotExist(err) {
-						// File doesn't exist in verify mode
-						hash = "missing"
-					} else {
-						return fmt.Errorf("failed to calculate hash for %s: %w", fullOutputPath, err)
-					}
+					return err
 				}
-
-				lockFiles = append(lockFiles, lock.File{
-					Path:       outputPath,
-					SourcePath: file,
-					Hash:       hash,
-				})
+				if info.IsDir() {
+					return nil
+				}
+				relPath, _ := filepath.Rel(fullOutputDir, path)
+				if !removed[filepath.Join(outputDir, relPath)] {
+					future[relPath] = true
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to scan for conflicts: %w", err)
 			}
 		}
 
-		// Clean up orphaned files if this source was previously installed
-		if oldFiles, exists := oldFilesBySource[url]; exists && !i.opts.VerifyOnly {
-			orphanedFiles := i.findOrphanedFiles(oldFiles, lockFiles)
-			for _, orphan := range orphanedFiles {
-				fullPath := filepath.Join(i.opts.WorkspaceDir, orphan)
-				if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
-					// Log warning but don't fail the entire operation
-					fmt.Printf("Warning: could not remove orphaned file %s: %v\n", orphan, err)
-				}
+		for path := range renderedFiles {
+			if relPath, err := filepath.Rel(outputDir, filepath.Clean(path)); err == nil && !strings.HasPrefix(relPath, "..") {
+				future[relPath] = true
 			}
 		}
 
-		lockSources = append(lockSources, lock.Source{
-			URL:    url,
-			Ref:    ref,
-			Commit: commit,
-			Files:  lockFiles,
-		})
+		files := make([]string, 0, len(future))
+		for path := range future {
+			files = append(files, path)
+		}
+		sort.Strings(files)
+
+		issues := i.conflictDetector.ScanFiles(files)
+		if len(issues) > 0 && i.opts.StrictMode {
+			return fmt.Errorf("conflicts detected: %v", issues)
+		}
 	}
 
-	// Check for conflicts
-	for name := range i.adapters {
-		if !i.isAdapterEnabled(cfg, name) {
+	return nil
+}
+
+// removeEmptyDirs removes the directories above a removed file that are left
+// empty, such as the subdirectories of nested layouts. Output directories of
+// the enabled adapters are kept.
+func (i *Installer) removeEmptyDirs(adapters []adapter.AgentAdapter, path string) {
+	keep := map[string]bool{".": true}
+	for _, a := range adapters {
+		keep[filepath.Clean(a.TargetDir(adapter.ScopeProject))] = true
+	}
+	for _, dir := range scanDirs(adapters) {
+		keep[filepath.Clean(dir)] = true
+	}
+
+	for dir := filepath.Dir(filepath.Clean(path)); !keep[dir]; dir = filepath.Dir(dir) {
+		// Remove fails on directories that still hold files
+		if err := os.Remove(filepath.Join(i.opts.WorkspaceDir, dir)); err != nil {
+			return
+		}
+	}
+}
+
+// scanDirs returns the output directories checked for duplicate basenames
+func scanDirs(adapters []adapter.AgentAdapter) []string {
+	var dirs []string
+	for _, a := range adapters {
+		if scanner, ok := a.(adapter.Scanner); ok {
+			dirs = append(dirs, scanner.ScanDirs()...)
 			continue
 		}
+		outputDir := filepath.Clean(a.TargetDir(adapter.ScopeProject))
+		if outputDir == "." {
+			continue // Shared with the rest of the workspace, not scanned
+		}
+		dirs = append(dirs, outputDir)
+	}
+	return dirs
+}
 
-		adapterCfg := i.getAdapterConfig(cfg, name)
-		outputDir := i.adapters[name].GetBaseOutputDir(adapterCfg)
+// verify checks the installed files for conflicts, drift from the lock file
+// and security levels that increased without approval
+func (i *Installer) verify(adapters []adapter.AgentAdapter, results []preparedSource, lockData *lock.Lock, lockedFiles map[string]lock.File) error {
+	for _, outputDir := range scanDirs(adapters) {
 		fullOutputDir := filepath.Join(i.opts.WorkspaceDir, outputDir)
 
 		if info, err := os.Stat(fullOutputDir); err == nil && info.IsDir() {
@@ -270,74 +583,519 @@ func (i *Installer) Execute() error {
 		}
 	}
 
-	// In verify mode, check for drift
-	if i.opts.VerifyOnly {
-		existingHashes, err := i.lockWriter.GetFileHashes()
+	existingHashes, err := i.lockWriter.GetFileHashes()
+	if err != nil {
+		return fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	// Managed sections only cover part of their file
+	sectionHashes := make(map[string]string)
+	for path, file := range lockedFiles {
+		if file.Section {
+			sectionHashes[path] = file.Hash
+			delete(existingHashes, path)
+		}
+	}
+
+	issues, err := i.conflictDetector.CheckDrift(existingHashes)
+	if err != nil {
+		return fmt.Errorf("failed to check drift: %w", err)
+	}
+	sectionIssues, err := i.conflictDetector.CheckSectionDrift(sectionHashes)
+	if err != nil {
+		return fmt.Errorf("failed to check drift: %w", err)
+	}
+	issues = append(issues, sectionIssues...)
+
+	if len(issues) > 0 {
+		return fmt.Errorf("drift detected: %v", issues)
+	}
+
+	if increases := securityIncreases(results, lockData, lockedFiles); len(increases) > 0 {
+		return fmt.Errorf("security level increased without approval (run install --approve-security):\n  %s", strings.Join(increases, "\n  "))
+	}
+
+	return nil // Verification passed
+}
+
+// checkSecurityLevels reports prompts above the security threshold and prompts
+// whose level increased since the lock file was written. Both are warnings
+// unless running in strict mode.
+func (i *Installer) checkSecurityLevels(results []preparedSource, threshold security.Level, oldLock *lock.Lock, lockedFiles map[string]lock.File) error {
+	var problems []string
+	if threshold != security.LevelNone {
+		for _, result := range results {
+			for _, file := range result.files {
+				if file.securityLevel > threshold {
+					problems = append(problems, fmt.Sprintf("%s: security level %s exceeds the threshold %s", file.outputPath, file.securityLevel, threshold))
+				}
+			}
+		}
+	}
+	if !i.opts.ApproveSecurity {
+		for _, increase := range securityIncreases(results, oldLock, lockedFiles) {
+			problems = append(problems, increase+" (approve with --approve-security)")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	if i.opts.StrictMode {
+		return fmt.Errorf("security check failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+	for _, problem := range problems {
+		fmt.Printf("Warning: %s\n", problem)
+	}
+	return nil
+}
+
+// securityIncreases lists rendered files whose security level is higher than
+// the level approved in the lock file
+func securityIncreases(results []preparedSource, lockData *lock.Lock, lockedFiles map[string]lock.File) []string {
+	var increases []string
+	for _, result := range results {
+		for _, file := range result.files {
+			old, found := lockedFiles[file.outputPath]
+			if approved := approvedLevel(lockData, old, found, file.securityLevel); file.securityLevel > approved {
+				increases = append(increases, fmt.Sprintf("%s: security level increased from %s to %s", file.outputPath, approved, file.securityLevel))
+			}
+		}
+	}
+	return increases
+}
+
+// approvedLevel returns the security level approved for a locked file. Files
+// that are new or come from a lock without security levels are approved at
+// their current level.
+func approvedLevel(lockData *lock.Lock, old lock.File, found bool, current security.Level) security.Level {
+	if !found || !lockData.TracksSecurity() {
+		return current
+	}
+
+	name := old.ApprovedSecurity
+	if name == "" {
+		name = old.Security
+	}
+	level, err := security.ParseLevel(name)
+	if err != nil {
+		return security.LevelNone
+	}
+	return level
+}
+
+// preparedSource is a fetched source with its files rendered in memory
+type preparedSource struct {
+	url      string
+	ref      string
+	scope    string
+	commit   string
+	version  string
+	pack     adapter.PromptPack
+	files    []renderedFile
+	shadowed []lock.ShadowedFile // Files replaced by a source in a higher scope
+	skipped  []lock.SkippedFile  // Prompts whose targets leave out an adapter
+
+	packVersion  string            // Version declared in the pack's pack.yaml
+	dependencies []lock.Dependency // Packs the pack.yaml requires
+
+	notes []string // Printed once every source is prepared, in source order
+}
+
+// renderedFile is the output of one adapter for one source file
+type renderedFile struct {
+	outputPath    string
+	sourcePath    string
+	content       []byte
+	securityLevel security.Level
+	composer      adapter.Composer // Set for fragments
+	section       bool             // Content is the managed section of the file
+	target        string           // targets entry that selected the adapter, if any
+	includes      []string         // Files included into the source file
+}
+
+// prepareSources fetches and renders every source using a bounded pool of
+// workers. Every source is fetched before any is rendered so prompts can
+// include files from other named sources, and the packs their pack.yaml
+// manifests require are added after them. Results are returned in the order
+// of sources, and when several sources fail the error of the first one in
+// that order is reported.
+func (i *Installer) prepareSources(adapters []adapter.AgentAdapter, sources []config.SourceCfg, scopes []string, lockedSources map[string]lock.Source, cfg *config.ExtendedConfig) ([]preparedSource, error) {
+	fetched := make([]fetchedSource, len(sources))
+	err := i.forEachSource(len(sources), func(idx int) error {
+		var err error
+		fetched[idx], err = i.fetch(sources[idx], lockedSources)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Required packs are fetched one at a time as the graph is walked
+	resolver := newDependencyResolver(i, cfg, lockedSources, sources, scopes, fetched)
+	if err := resolver.resolve(); err != nil {
+		return nil, err
+	}
+	sources, scopes, fetched = resolver.sources, resolver.scopes, resolver.fetched
+
+	// Pack roots of named sources, for includes across sources
+	packs := make(map[string]string)
+	for idx, source := range sources {
+		if name := i.sourceName(source); name != "" {
+			packs[name] = fetched[idx].packPath
+		}
+	}
+
+	results := make([]preparedSource, len(sources))
+	err = i.forEachSource(len(sources), func(idx int) error {
+		var err error
+		results[idx], err = i.prepareSource(adapters, sources[idx], scopes[idx], fetched[idx], cfg.Variables(sources[idx]), packs)
+		results[idx].dependencies = resolver.deps[idx]
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Workers run concurrently, so warnings and notes are printed afterwards
+	// in order
+	for _, result := range results {
+		for _, warning := range result.pack.Warnings.Messages() {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+		for _, note := range result.notes {
+			fmt.Printf("Note: %s\n", note)
+		}
+	}
+	return results, nil
+}
+
+// forEachSource runs fn for every source index on a bounded pool of workers
+// and returns the error of the first failing index
+func (i *Installer) forEachSource(count int, fn func(idx int) error) error {
+	errs := make([]error, count)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < i.jobs(count); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				errs[idx] = fn(idx)
+			}
+		}()
+	}
+	for idx := 0; idx < count; idx++ {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			return fmt.Errorf("failed to read lock file: %w", err)
+			return err
 		}
+	}
+	return nil
+}
 
-		issues, err := i.conflictDetector.CheckDrift(existingHashes)
+// fetchedSource is a source checked out at the commit it is installed from
+type fetchedSource struct {
+	repoPath string
+	packPath string // Directory of the source within the checkout
+	commit   string
+	version  string
+	manifest *pack.Manifest // nil when the directory has no pack.yaml
+}
+
+// fetch checks out a source and reads the manifest of its pack
+func (i *Installer) fetch(source config.SourceCfg, lockedSources map[string]lock.Source) (fetchedSource, error) {
+	// Check out the locked commit or resolve the ref
+	repoPath, commit, tag, err := i.fetchSource(source, lockedSources)
+	if err != nil {
+		return fetchedSource{}, err
+	}
+	fetched := fetchedSource{repoPath: repoPath, packPath: repoPath, commit: commit, version: tag}
+
+	// A source may be a directory of its repository, and a ruleset or a
+	// dependency selects one of the packs in it
+	if source.Subdirectory != "" {
+		fetched.packPath = filepath.Join(repoPath, filepath.FromSlash(source.Subdirectory))
+		if info, err := os.Stat(fetched.packPath); err != nil || !info.IsDir() {
+			return fetchedSource{}, fmt.Errorf("%s: directory %s not found in %s", source.URL(), source.Subdirectory, source.Repo)
+		}
+	}
+	if source.Pack != "" {
+		dir, err := pack.Resolve(fetched.packPath, source.Pack)
 		if err != nil {
-			return fmt.Errorf("failed to check drift: %w", err)
+			if source.Ruleset != "" {
+				err = fmt.Errorf("ruleset %s: %w", source.Ruleset, err)
+			}
+			return fetchedSource{}, err
 		}
+		fetched.packPath = filepath.Join(fetched.packPath, filepath.FromSlash(dir))
+	}
 
-		if len(issues) > 0 {
-			return fmt.Errorf("drift detected: %v", issues)
+	fetched.manifest, err = pack.LoadManifest(fetched.packPath)
+	if err != nil {
+		return fetchedSource{}, err
+	}
+	if fetched.manifest != nil {
+		if fetched.manifest.Name == "" {
+			fetched.manifest.Name = path.Base(source.URL())
+		}
+		if err := fetched.manifest.CheckToolVersion(version.Version); err != nil {
+			return fetchedSource{}, err
 		}
+	}
+	return fetched, nil
+}
 
-		return nil // Verification passed
+// prepareSource renders a fetched source with every enabled adapter it
+// allows, substituting the Promptsfile variables into prompt bodies
+func (i *Installer) prepareSource(adapters []adapter.AgentAdapter, source config.SourceCfg, scope string, fetched fetchedSource, vars map[string]interface{}, packs map[string]string) (preparedSource, error) {
+	url, ref := source.URL(), source.Ref
+	repoPath, commit, ver := fetched.packPath, fetched.commit, fetched.version
+
+	// Sources may be named and declare their own prefix in the Promptsfile
+	// or in the trusted source that allows them
+	promptPack := adapter.PromptPack{Name: url, Path: repoPath, Ref: ref, Vars: vars, StrictVars: i.opts.StrictMode, Warnings: &adapter.Warnings{}}
+	promptPack.Source = i.sourceName(source)
+	promptPack.Prefix = source.Prefix
+	if trusted, ok := i.trustedSources.TrustedBy(source.Repo); ok && promptPack.Prefix == "" {
+		promptPack.Prefix = trusted.ClaudePrefix
+	}
+
+	// Front-matter and includes are parsed once and shared by every adapter
+	prompts, err := adapter.LoadPrompts(repoPath, packs)
+	if err != nil {
+		return preparedSource{}, fmt.Errorf("failed to read prompts of %s: %w", url, err)
+	}
+	promptPack.Prompts = prompts
+	promptPaths := make([]string, 0, len(prompts))
+	for path := range prompts {
+		promptPaths = append(promptPaths, path)
 	}
+	sort.Strings(promptPaths)
 
-	// Update .gitignore
-	var ignorePatterns []string
-	for name, adapterImpl := range i.adapters {
-		if !i.isAdapterEnabled(cfg, name) {
+	result := preparedSource{url: url, ref: ref, scope: scope, commit: commit, version: ver, pack: promptPack}
+	if fetched.manifest != nil {
+		result.packVersion = fetched.manifest.Version
+	}
+
+	// Security levels of other files, such as the supporting files of a skill,
+	// may come from metadata.yaml as well as front-matter
+	metadata, err := adapter.LoadMetadataFile(filepath.Join(repoPath, "prompts", "metadata.yaml"))
+	if err != nil {
+		return preparedSource{}, fmt.Errorf("failed to load metadata of %s: %w", url, err)
+	}
+	levels := make(map[string]security.Level) // source path -> level
+
+	for _, a := range adapters {
+		if !source.Adapters.Allows(a.Name()) {
 			continue
 		}
+		if fetched.manifest != nil && !fetched.manifest.Supports(a.Name()) {
+			result.notes = append(result.notes, fmt.Sprintf("pack %s does not support %s and is not rendered for it", fetched.manifest.Name, a.Name()))
+			continue
+		}
+		files, err := a.Render(promptPack, adapter.Scope(scope))
+		if err != nil {
+			return preparedSource{}, fmt.Errorf("failed to render %s for %s: %w", url, a.Name(), err)
+		}
+
+		for _, file := range files {
+			prompt, isPrompt := prompts[file.SourcePath]
+			level, ok := levels[file.SourcePath]
+			if !ok && isPrompt {
+				level, err = security.LevelFromMetadata(prompt.Meta)
+				if err != nil {
+					return preparedSource{}, fmt.Errorf("failed to read security level of %s: %w", file.SourcePath, err)
+				}
+				levels[file.SourcePath] = level
+			} else if !ok {
+				content, err := os.ReadFile(filepath.Join(repoPath, file.SourcePath))
+				if err != nil {
+					return preparedSource{}, fmt.Errorf("failed to read %s: %w", file.SourcePath, err)
+				}
+				level, err = securityLevel(metadata, file.SourcePath, content)
+				if err != nil {
+					return preparedSource{}, fmt.Errorf("failed to read security level of %s: %w", file.SourcePath, err)
+				}
+				levels[file.SourcePath] = level
+			}
+
+			rendered := renderedFile{
+				outputPath:    filepath.Join(a.TargetDir(adapter.Scope(scope)), file.Path),
+				sourcePath:    file.SourcePath,
+				content:       file.Content,
+				securityLevel: level,
+				section:       file.Section,
+			}
+			if isPrompt {
+				rendered.target = adapter.Route(prompt.Meta, a.Name()).Target
+				rendered.includes = prompt.Includes
+			}
+			if file.Fragment {
+				composer, ok := a.(adapter.Composer)
+				if !ok {
+					return preparedSource{}, fmt.Errorf("adapter %s rendered a fragment for %s but cannot compose it", a.Name(), rendered.outputPath)
+				}
+				rendered.composer = composer
+			}
+			result.files = append(result.files, rendered)
+		}
+
+		// Record why prompts that declare targets were left out
+		for _, path := range promptPaths {
+			if routing := adapter.Route(prompts[path].Meta, a.Name()); !routing.Rendered {
+				result.skipped = append(result.skipped, lock.SkippedFile{SourcePath: path, Adapter: a.Name(), Reason: routing.Reason})
+			}
+		}
+	}
+
+	return result, nil
+}
 
-		adapterCfg := i.getAdapterConfig(cfg, name)
-		patterns := adapterImpl.GetGitignorePatterns(adapterCfg)
-		ignorePatterns = append(ignorePatterns, patterns...)
+// securityLevel resolves a prompt's security level from metadata.yaml defaults,
+// per-file overrides and front-matter, in increasing order of precedence
+func securityLevel(metadata *adapter.Metadata, file string, content []byte) (security.Level, error) {
+	frontMatter, _, err := adapter.ParseFrontMatter(content)
+	if err != nil {
+		return security.LevelNone, err
 	}
+	merged := adapter.MergeMetadata(metadata.Defaults, metadata.Files[filepath.Base(file)], frontMatter)
+	return security.LevelFromMetadata(merged)
+}
 
-	if err := i.gitignoreManager.Update(ignorePatterns); err != nil {
-		return fmt.Errorf("failed to update .gitignore: %w", err)
+// jobs returns the number of workers to use for n sources
+func (i *Installer) jobs(n int) int {
+	jobs := i.opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
 	}
+	if jobs > n {
+		jobs = n
+	}
+	return jobs
+}
 
-	// Write lock file
-	if err := i.lockWriter.Write(lockSources); err != nil {
-		return fmt.Errorf("failed to write lock file: %w", err)
+// fetchSource materialises the repository of a source and returns its local
+// path, commit and, for version ranges, the selected tag. Sources whose ref
+// still matches the lock file are checked out at the locked commit so
+// installs are reproducible; new sources, changed refs and sources selected
+// for update are resolved from the remote.
+func (i *Installer) fetchSource(source config.SourceCfg, lockedSources map[string]lock.Source) (string, string, string, error) {
+	url, ref := source.Repo, source.Ref
+	if locked, ok := lockedSources[source.URL()]; ok && locked.Ref == ref && locked.Commit != "" && !i.isUpdateRequested(source.URL()) {
+		repoPath, err := i.gitFetcher.CheckoutCommit(url, locked.Commit)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to check out locked commit %s of %s: %w", locked.Commit, url, err)
+		}
+		return repoPath, locked.Commit, locked.Version, nil
 	}
 
-	return nil
+	// Version ranges are resolved against the remote tags
+	if semver.IsConstraint(ref) {
+		remote, err := i.gitFetcher.ResolveRemote(url, ref)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to resolve %s of %s: %w", ref, url, err)
+		}
+		repoPath, err := i.gitFetcher.CheckoutCommit(url, remote.Commit)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to fetch %s: %w", url, err)
+		}
+		return repoPath, remote.Commit, remote.Version, nil
+	}
+
+	repoPath, commit, err := i.gitFetcher.CloneOrUpdate(url, ref)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	return repoPath, commit, "", nil
+}
+
+// sourceName returns the name of a source, declared in the Promptsfile or by
+// the trusted source that allows it
+func (i *Installer) sourceName(source config.SourceCfg) string {
+	if source.Name != "" {
+		return source.Name
+	}
+	if trusted, ok := i.trustedSources.TrustedBy(source.Repo); ok {
+		return trusted.Name
+	}
+	return ""
 }
 
-func (i *Installer) isAdapterEnabled(cfg *config.ExtendedConfig, name string) bool {
-	switch name {
-	case "cursor":
-		return cfg.Adapters.Cursor.Enabled
-	case "claude":
-		return cfg.Adapters.Claude.Enabled
-	default:
+// isUpdateRequested reports whether the source was explicitly selected for update
+func (i *Installer) isUpdateRequested(url string) bool {
+	if i.opts.FrozenLockfile {
 		return false
 	}
+	for _, source := range i.opts.UpdateSources {
+		if strings.Split(source, "#")[0] == url {
+			return true
+		}
+	}
+	return false
 }
 
-func (i *Installer) getAdapterConfig(cfg *config.ExtendedConfig, name string) adapter.Config {
-	switch name {
-	case "cursor":
-		return adapter.Config{
-			Enabled: cfg.Adapters.Cursor.Enabled,
+// checkLockfileInSync verifies that every configured source is locked with the
+// same ref and that the lock file has no entries for removed sources.
+func checkLockfileInSync(sources []config.SourceCfg, lockData *lock.Lock) error {
+	if lockData == nil {
+		return fmt.Errorf("frozen lockfile: Promptsfile.lock not found, run install without --frozen-lockfile first")
+	}
+
+	locked := make(map[string]lock.Source)
+	for _, source := range lockData.Sources {
+		locked[strings.Split(source.URL, "#")[0]] = source
+	}
+
+	var problems []string
+	configured := make(map[string]bool)
+	for _, source := range sources {
+		url, ref := source.URL(), source.Ref
+		configured[url] = true
+
+		entry, ok := locked[url]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is not in the lock file", url))
+		case entry.Ref != ref:
+			problems = append(problems, fmt.Sprintf("%s ref changed from %q to %q", url, entry.Ref, ref))
 		}
-	case "claude":
-		return adapter.Config{
-			Enabled: cfg.Adapters.Claude.Enabled,
-			Prefix:  cfg.Adapters.Claude.Prefix,
+	}
+
+	// Packs required by configured packs are locked along with them
+	queue := make([]string, 0, len(configured))
+	for url := range configured {
+		queue = append(queue, url)
+	}
+	for len(queue) > 0 {
+		url := queue[0]
+		queue = queue[1:]
+		for _, dep := range locked[url].Dependencies {
+			if !configured[dep.URL] {
+				configured[dep.URL] = true
+				queue = append(queue, dep.URL)
+			}
+		}
+	}
+
+	for _, source := range lockData.Sources {
+		url := strings.Split(source.URL, "#")[0]
+		if !configured[url] {
+			problems = append(problems, fmt.Sprintf("%s was removed from Promptsfile", url))
 		}
-	default:
-		return adapter.Config{}
 	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("frozen lockfile: Promptsfile.lock is out of date: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
 }
 
 // findOrphanedFiles returns files that exist in oldFiles but not in newFiles
@@ -0,0 +1,185 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kovyrin/prompt-sync/internal/adapter"
+	"github.com/kovyrin/prompt-sync/internal/lock"
+	"github.com/kovyrin/prompt-sync/internal/security"
+)
+
+// Scopes in increasing order of precedence. A file rendered by a source in a
+// higher scope shadows the file with the same output path from a lower scope.
+const (
+	scopeOrg      = "org"
+	scopeProject  = "project"
+	scopePersonal = "personal"
+)
+
+var scopePrecedence = map[string]int{
+	scopeOrg:      0,
+	scopeProject:  1,
+	scopePersonal: 2,
+}
+
+// normalizeScope validates an overlay scope. Sources without a scope belong
+// to the project.
+func normalizeScope(scope string) (string, error) {
+	if scope == "" {
+		return scopeProject, nil
+	}
+	if _, ok := scopePrecedence[scope]; !ok {
+		return "", fmt.Errorf("invalid overlay scope %q (want org, project or personal)", scope)
+	}
+	return scope, nil
+}
+
+// resolveShadowing decides which source renders each output path. When two
+// sources produce the same path, the one in the higher scope wins and the
+// other file is moved to its source's shadowed list. Two sources in the same
+// scope producing the same path is a conflict. Fragments are left to
+// composeFragments.
+func resolveShadowing(results []preparedSource) error {
+	type owner struct {
+		result int
+		file   renderedFile
+	}
+	winners := make(map[string]owner)
+	shadowedBy := make(map[string]bool) // "result/path" of files that lost
+
+	for idx, result := range results {
+		for _, file := range result.files {
+			if file.composer != nil {
+				continue
+			}
+
+			existing, exists := winners[file.outputPath]
+			if !exists {
+				winners[file.outputPath] = owner{result: idx, file: file}
+				continue
+			}
+
+			current := results[existing.result]
+			switch {
+			case scopePrecedence[result.scope] == scopePrecedence[current.scope]:
+				return fmt.Errorf("conflict: %s would be rendered by both %s and %s", file.outputPath, current.url, result.url)
+			case scopePrecedence[result.scope] > scopePrecedence[current.scope]:
+				shadowedBy[fmt.Sprintf("%d/%s", existing.result, file.outputPath)] = true
+				winners[file.outputPath] = owner{result: idx, file: file}
+			default:
+				shadowedBy[fmt.Sprintf("%d/%s", idx, file.outputPath)] = true
+			}
+		}
+	}
+
+	for idx := range results {
+		var kept []renderedFile
+		for _, file := range results[idx].files {
+			if !shadowedBy[fmt.Sprintf("%d/%s", idx, file.outputPath)] {
+				kept = append(kept, file)
+				continue
+			}
+			winner := results[winners[file.outputPath].result]
+			results[idx].shadowed = append(results[idx].shadowed, lock.ShadowedFile{
+				Path:       file.outputPath,
+				SourcePath: file.sourcePath,
+				By:         winner.url,
+				ByScope:    winner.scope,
+			})
+		}
+		results[idx].files = kept
+	}
+
+	return nil
+}
+
+// composeFragments replaces the fragments rendered at each path with the file
+// their adapter composes from them, passing fragments from the lowest to the
+// highest scope. Every source that contributed records the composed file.
+func composeFragments(results []preparedSource) error {
+	type part struct {
+		result int
+		file   renderedFile
+	}
+	groups := make(map[string][]part)
+	var paths []string
+	for idx, result := range results {
+		for _, file := range result.files {
+			if file.composer == nil {
+				continue
+			}
+			if _, exists := groups[file.outputPath]; !exists {
+				paths = append(paths, file.outputPath)
+			}
+			groups[file.outputPath] = append(groups[file.outputPath], part{result: idx, file: file})
+		}
+	}
+
+	for _, path := range paths {
+		group := groups[path]
+		sort.SliceStable(group, func(a, b int) bool {
+			return scopePrecedence[results[group[a].result].scope] < scopePrecedence[results[group[b].result].scope]
+		})
+
+		fragments := make([]adapter.Fragment, 0, len(group))
+		level := security.LevelNone
+		sourcePaths := make(map[int][]string)
+		includes := make(map[int][]string)
+		for _, p := range group {
+			result := results[p.result]
+			fragments = append(fragments, adapter.Fragment{
+				Pack:       result.url,
+				Scope:      adapter.Scope(result.scope),
+				SourcePath: p.file.sourcePath,
+				Content:    p.file.content,
+			})
+			if p.file.securityLevel > level {
+				level = p.file.securityLevel
+			}
+			sourcePaths[p.result] = append(sourcePaths[p.result], p.file.sourcePath)
+			includes[p.result] = append(includes[p.result], p.file.includes...)
+		}
+
+		content, err := group[0].file.composer.Compose(path, fragments)
+		if err != nil {
+			return fmt.Errorf("failed to compose %s: %w", path, err)
+		}
+
+		for idx, contributed := range sourcePaths {
+			var kept []renderedFile
+			for _, file := range results[idx].files {
+				if file.composer == nil || file.outputPath != path {
+					kept = append(kept, file)
+				}
+			}
+			results[idx].files = append(kept, renderedFile{
+				outputPath:    path,
+				sourcePath:    strings.Join(contributed, ", "),
+				content:       content,
+				securityLevel: level,
+				section:       group[0].file.section,
+				includes:      uniqueSorted(includes[idx]),
+			})
+		}
+	}
+
+	return nil
+}
+
+// uniqueSorted returns the distinct values sorted, nil when there are none
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	unique := sorted[:1]
+	for _, value := range sorted[1:] {
+		if value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,327 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// transactionDirName is the directory in the workspace that holds the staged
+// output, the backups and the journal of a running install.
+const transactionDirName = ".prompt-sync-txn"
+
+// transaction applies the output of an install to the workspace as one unit.
+// The install owns the transaction directory from its start, so concurrent
+// installs in the same workspace fail instead of undoing each other.
+// Rendered files are staged first; before anything in the workspace changes,
+// every affected path is backed up and recorded in a journal. A failed install
+// is rolled back from the backups, and an interrupted one is rolled back by
+// the next install that finds the journal.
+type transaction struct {
+	workspaceDir string
+	dir          string
+	entries      []journalEntry
+	done         bool
+}
+
+// journalEntry records the state of a path before the install touched it
+type journalEntry struct {
+	Path   string `json:"path"`             // Absolute path that may change
+	Backup string `json:"backup,omitempty"` // Copy of the original, empty if the path did not exist
+	Dir    bool   `json:"dir,omitempty"`    // Directory the install may create, removed again if empty
+}
+
+// ownerFileName is the file in the transaction directory that holds the
+// process ID of the install that owns it
+const ownerFileName = "owner"
+
+// beginTransaction takes ownership of the workspace's transaction directory
+// for this install. The directory of an install whose process is gone is
+// rolled back first; recovered reports whether that happened.
+func beginTransaction(workspaceDir string) (t *transaction, recovered bool, err error) {
+	t = &transaction{
+		workspaceDir: workspaceDir,
+		dir:          filepath.Join(workspaceDir, transactionDirName),
+	}
+
+	for {
+		err := t.lock()
+		if err == nil {
+			return t, recovered, nil
+		}
+		if !os.IsExist(err) {
+			return nil, recovered, fmt.Errorf("failed to create staging directory: %w", err)
+		}
+
+		if pid := transactionOwner(t.dir); pid != 0 && processRunning(pid) {
+			return nil, recovered, fmt.Errorf("another install (pid %d) is in progress", pid)
+		}
+		rolledBack, err := recoverTransaction(workspaceDir)
+		if err != nil {
+			return nil, recovered, fmt.Errorf("failed to recover interrupted install: %w", err)
+		}
+		recovered = recovered || rolledBack
+	}
+}
+
+// lock creates the transaction directory with its owner file already in
+// place, so other installs never see a directory without an owner. It fails
+// with an os.IsExist error while another transaction directory exists.
+func (t *transaction) lock() error {
+	tmpDir, err := os.MkdirTemp(t.workspaceDir, transactionDirName+".*")
+	if err != nil {
+		return err
+	}
+	owner := filepath.Join(tmpDir, ownerFileName)
+	if err := os.WriteFile(owner, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.Rename(tmpDir, t.dir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	return nil
+}
+
+// transactionOwner returns the process ID of the install owning a
+// transaction directory, or 0 when the directory records no owner
+func transactionOwner(dir string) int {
+	data, err := os.ReadFile(filepath.Join(dir, ownerFileName))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// processRunning reports whether a process with the given ID exists
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// recoverTransaction rolls back an install that was interrupted after it
+// started changing the workspace. Callers must make sure the install that
+// owned the transaction directory is gone. It reports whether a rollback
+// happened.
+func recoverTransaction(workspaceDir string) (bool, error) {
+	dir := filepath.Join(workspaceDir, transactionDirName)
+
+	data, err := os.ReadFile(filepath.Join(dir, "journal.json"))
+	if os.IsNotExist(err) {
+		// Without a journal the workspace was never touched
+		if err := os.RemoveAll(dir); err != nil {
+			return false, fmt.Errorf("failed to remove stale staging directory: %w", err)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read install journal: %w", err)
+	}
+
+	t := &transaction{workspaceDir: workspaceDir, dir: dir}
+	if err := json.Unmarshal(data, &t.entries); err != nil {
+		return false, fmt.Errorf("failed to parse install journal: %w", err)
+	}
+
+	if err := t.rollback(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// stage writes rendered content to the staging area and returns the staged path.
+func (t *transaction) stage(relPath string, content []byte) (string, error) {
+	stagedPath := t.stagedPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(stagedPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Dir(stagedPath), err)
+	}
+	if err := os.WriteFile(stagedPath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to stage %s: %w", relPath, err)
+	}
+	return stagedPath, nil
+}
+
+func (t *transaction) stagedPath(relPath string) string {
+	return filepath.Join(t.dir, "staged", relPath)
+}
+
+// commit backs up every path in paths, records them in the journal and then
+// runs apply. If apply fails, every path is restored to its original state.
+func (t *transaction) commit(paths []string, apply func() error) error {
+	backupDir := filepath.Join(t.dir, "backup")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		// Missing parent directories are journaled first, so a rollback
+		// removes them after the files inside
+		for _, dir := range t.missingDirs(filepath.Dir(path)) {
+			if !seen[dir] {
+				seen[dir] = true
+				t.entries = append(t.entries, journalEntry{Path: dir, Dir: true})
+			}
+		}
+
+		entry := journalEntry{Path: path}
+		if _, err := os.Lstat(path); err == nil {
+			entry.Backup = filepath.Join(backupDir, strconv.Itoa(len(t.entries)))
+			if err := copyFile(path, entry.Backup); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		}
+		t.entries = append(t.entries, entry)
+	}
+
+	if err := t.writeJournal(); err != nil {
+		return err
+	}
+
+	if err := apply(); err != nil {
+		if rbErr := t.rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	t.done = true
+	return os.RemoveAll(t.dir)
+}
+
+// install moves a staged file into its place in the workspace.
+func (t *transaction) install(relPath string) error {
+	target := filepath.Join(t.workspaceDir, relPath)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(target), err)
+	}
+	if err := os.Rename(t.stagedPath(relPath), target); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// rollback restores every journaled path and removes the transaction directory.
+func (t *transaction) rollback() error {
+	for i := len(t.entries) - 1; i >= 0; i-- {
+		entry := t.entries[i]
+		if entry.Dir {
+			if err := removeEmptyDir(entry.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+			continue
+		}
+		if entry.Backup == "" {
+			if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+		if err := copyFile(entry.Backup, entry.Path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+
+	t.done = true
+	return os.RemoveAll(t.dir)
+}
+
+// missingDirs returns the directories from dir up to the workspace that do
+// not exist yet, outermost first
+func (t *transaction) missingDirs(dir string) []string {
+	var missing []string
+	for {
+		rel, err := filepath.Rel(t.workspaceDir, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return missing
+		}
+		if _, err := os.Lstat(dir); err == nil {
+			return missing
+		}
+		missing = append([]string{dir}, missing...)
+		dir = filepath.Dir(dir)
+	}
+}
+
+// removeEmptyDir removes a directory unless it is missing or holds anything
+func removeEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) || (err == nil && len(entries) > 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(dir)
+}
+
+// discard removes the staging area of a transaction that never committed.
+func (t *transaction) discard() {
+	if !t.done {
+		os.RemoveAll(t.dir)
+	}
+}
+
+// writeJournal atomically writes the journal that marks the start of changes
+func (t *transaction) writeJournal() error {
+	data, err := json.MarshalIndent(t.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install journal: %w", err)
+	}
+
+	tmpPath := filepath.Join(t.dir, "journal.json.tmp")
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(t.dir, "journal.json")); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies a regular file, preserving its permissions
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}